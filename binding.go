@@ -1,8 +1,11 @@
 package gts
 
 import (
+	"bytes"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -119,7 +122,7 @@ func bindWithMap(objType reflect.Type, currentObjValue, objValue, cleanDataValue
 		}
 
 		var value, exists = source[tag]
-		if !exists {
+		if !exists || value == nil { // JSON 中的 null 视为未提交
 			if ok := setDefaultValue(currentObjValue, objValue, fieldValue, fieldStruct); !ok {
 				continue
 			}
@@ -216,11 +219,28 @@ func _setValue(fieldValue reflect.Value, fieldStruct reflect.StructField, value
 	var fieldKind = fieldValue.Kind()
 
 	if valueKind == reflect.Slice {
+		if value.Len() == 0 {
+			// 空数组，如 JSON 中的 []，视为零值
+			fieldValue.Set(reflect.Zero(fieldValue.Type()))
+			return nil
+		}
 		// 如果源数据是 slice, 则取出其第一个数据
 		value = value.Index(0)
 		valueKind = value.Kind()
 	}
 
+	if valueKind == reflect.Interface {
+		// JSON 等解码得到的 []interface{} 元素
+		value = value.Elem()
+		valueKind = value.Kind()
+	}
+
+	if valueKind == reflect.Invalid {
+		// 数组中的 null
+		fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		return nil
+	}
+
 	if valueKind == fieldKind {
 		return _setValueWithSameKind(fieldValue, fieldStruct, valueKind, value)
 	}
@@ -260,7 +280,7 @@ func _setValueWithDiffKind(fieldValue reflect.Value, fieldStruct reflect.StructF
 
 	switch fieldValueKind {
 	case reflect.String:
-		fieldValue.SetString(fmt.Sprintf("%f", f))
+		fieldValue.SetString(strconv.FormatFloat(f, 'f', -1, 64))
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		fieldValue.SetInt(int64(f))
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
@@ -329,3 +349,64 @@ func floatValue(valueKind reflect.Kind, value reflect.Value) (float64, error) {
 	}
 	return 0.0, nil
 }
+
+// decodeXMLMap 将 XML 根元素的子元素解码为 map，叶子元素为字符串，同名元素为 []interface{}
+func decodeXMLMap(data []byte) (map[string]interface{}, error) {
+	var d = xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return make(map[string]interface{}), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			v, err := decodeXMLElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if m, ok := v.(map[string]interface{}); ok {
+				return m, nil
+			}
+			return make(map[string]interface{}), nil
+		}
+	}
+}
+
+func decodeXMLElement(d *xml.Decoder) (interface{}, error) {
+	var children map[string]interface{}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v, err := decodeXMLElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if children == nil {
+				children = make(map[string]interface{})
+			}
+			var key = t.Name.Local
+			switch old := children[key].(type) {
+			case nil:
+				children[key] = v
+			case []interface{}:
+				children[key] = append(old, v)
+			default:
+				children[key] = []interface{}{old, v}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if children != nil {
+				return children, nil
+			}
+			return strings.TrimSpace(text.String()), nil
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package gts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack"
+)
+
+type bindUser struct {
+	Name        string   `form:"name"`
+	Age         int      `form:"age"`
+	Tags        []string `form:"tags"`
+	Role        string   `form:"role"`
+	CleanedData map[string]interface{}
+}
+
+func (u *bindUser) CleanedName(v interface{}) (string, error) {
+	s, _ := v.(string)
+	return strings.TrimSpace(s), nil
+}
+
+func (u *bindUser) DefaultRole() string {
+	return "guest"
+}
+
+func shouldBind(t *testing.T, contentType, body string) (bindUser, error) {
+
+	t.Helper()
+
+	var u bindUser
+	var err error
+	r := New()
+	r.Post("/", func(req *http.Request, c *Context) {
+		err = c.ShouldBind(&u)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return u, err
+}
+
+func TestShouldBind(t *testing.T) {
+
+	mp, err := msgpack.Marshal(map[string]interface{}{"name": " mp ", "age": 5, "tags": []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, contentType, body string
+	}{
+		{"json", "application/json; charset=utf-8", `{"name": " mp ", "age": 5, "tags": ["a", "b"]}`},
+		{"xml", "application/xml", `<user><name> mp </name><age>5</age><tags>a</tags><tags>b</tags></user>`},
+		{"msgpack", "application/msgpack", string(mp)},
+		{"form", "application/x-www-form-urlencoded", `name=+mp+&age=5&tags=a&tags=b`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			u, err := shouldBind(t, tt.contentType, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.Name != "mp" || u.Age != 5 || !reflect.DeepEqual(u.Tags, []string{"a", "b"}) || u.Role != "guest" {
+				t.Fatalf("got %+v", u)
+			}
+			if u.CleanedData["role"] != "guest" {
+				t.Fatalf("CleanedData = %v", u.CleanedData)
+			}
+		})
+	}
+}
+
+func TestShouldBindEdgeCases(t *testing.T) {
+
+	u, err := shouldBind(t, "application/json", `{"name": [], "age": [], "role": null, "tags": [null]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "" || u.Age != 0 || u.Role != "guest" {
+		t.Fatalf("got %+v", u)
+	}
+
+	if _, err := shouldBind(t, "application/json", ``); err != nil {
+		t.Fatalf("empty body: %v", err)
+	}
+	if _, err := shouldBind(t, "application/json", `{bad`); err == nil {
+		t.Fatal("invalid json: want error")
+	}
+}
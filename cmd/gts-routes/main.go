@@ -0,0 +1,50 @@
+// gts-routes 打印 gts 应用的路由表
+//
+// 用法：
+//
+//	go run github.com/gkyh/gts/cmd/gts-routes [-json] ./path/to/app [app args...]
+//
+// 以 GTS_ROUTES 环境变量运行应用的 main 包，应用在调用 Router.Run 时输出路由表后退出，不会监听端口。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/gkyh/gts"
+)
+
+func main() {
+
+	asJSON := flag.Bool("json", false, "以 JSON 格式输出")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: gts-routes [-json] <app package> [app args...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	mode := "text"
+	if *asJSON {
+		mode = "json"
+	}
+
+	cmd := exec.Command("go", append([]string{"run", flag.Arg(0)}, flag.Args()[1:]...)...)
+	cmd.Env = append(os.Environ(), gts.RoutesEnv+"="+mode)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			os.Exit(exit.ExitCode())
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
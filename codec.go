@@ -0,0 +1,81 @@
+package gts
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// JSONCodec JSON 编解码，用于 Context 的 JSON 输出和 Bind，可替换为 jsoniter、sonic 等实现
+//
+//	route.UseJSON(jsoniter.ConfigCompatibleWithStandardLibrary)
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// StdJSON 基于 encoding/json 的默认实现
+type StdJSON struct {
+	NoEscapeHTML bool   //不转义 <、>、&，默认转义
+	Indent       string //缩进，调试时可设为 "  "，默认不缩进
+}
+
+func (s StdJSON) Marshal(v interface{}) ([]byte, error) {
+
+	if !s.NoEscapeHTML && s.Indent == "" {
+		return json.Marshal(v)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(!s.NoEscapeHTML)
+	enc.SetIndent("", s.Indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (s StdJSON) Unmarshal(data []byte, v interface{}) error {
+
+	return json.Unmarshal(data, v)
+}
+
+// ErrorHandlerFunc 统一的错误处理，如 JSON 序列化失败，见 Router.OnError
+type ErrorHandlerFunc func(err error, r *http.Request, c *Context)
+
+// UseJSON 设置 JSON 编解码，默认 StdJSON{}
+//
+//	route.UseJSON(gts.StdJSON{Indent: "  "}) //调试时格式化输出
+func (p *Router) UseJSON(codec JSONCodec) {
+
+	p.jsonCodec = codec
+}
+
+// OnError 设置统一的错误处理，默认记录日志，响应未提交时返回 500
+func (p *Router) OnError(h ErrorHandlerFunc) {
+
+	p.onError = h
+}
+
+// Error 交由 Router.OnError 设置的错误处理函数处理
+func (c *Context) Error(err error) {
+
+	if c.router != nil && c.router.onError != nil {
+		c.router.onError(err, c.Request, c)
+		return
+	}
+
+	c.router.print("error:", err)
+	if !c.Written() {
+		http.Error(c.Writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func (c *Context) jsonCodec() JSONCodec {
+
+	if c.router != nil && c.router.jsonCodec != nil {
+		return c.router.jsonCodec
+	}
+	return StdJSON{}
+}
@@ -0,0 +1,70 @@
+package gts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 记录调用次数的 JSONCodec
+type countingCodec struct {
+	StdJSON
+	n *int
+}
+
+func (c countingCodec) Marshal(v interface{}) ([]byte, error) {
+
+	*c.n++
+	return c.StdJSON.Marshal(v)
+}
+
+func TestUseJSON(t *testing.T) {
+
+	var n int
+	r := New()
+	api := r.Group("/api", nil)
+	api.UseJSON(countingCodec{StdJSON{Indent: " ", NoEscapeHTML: true}, &n})
+	api.Get("/json", func(req *http.Request, c *Context) { c.JSON(200, M{"h": "<b>"}) })
+	api.Get("/resp", func(req *http.Request, c *Context) { c.Resp().Data(1).JSON() })
+
+	if w := serve(r, http.MethodGet, "/api/json", nil); w.Body.String() != "{\n \"h\": \"<b>\"\n}" {
+		t.Errorf("json: got %q", w.Body.String())
+	}
+	if w := serve(r, http.MethodGet, "/api/resp", nil); w.Header().Get("Content-Type") != jsonContentType {
+		t.Errorf("resp: got %q", w.Header().Get("Content-Type"))
+	}
+	if n != 2 {
+		t.Errorf("codec called %d times, want 2", n)
+	}
+}
+
+func TestOnError(t *testing.T) {
+
+	var got error
+	r := New()
+	r.OnError(func(err error, req *http.Request, c *Context) {
+		got = err
+		c.Msg(500, "encode failed", 500)
+	})
+	r.Get("/", func(req *http.Request, c *Context) { c.JSON(200, M{"ch": make(chan int)}) })
+
+	w := serve(r, http.MethodGet, "/", nil)
+	if got == nil || w.Code != 500 || w.Body.String() != `{"msg":"encode failed","code":500,"data":null}` {
+		t.Fatalf("got %v %d %q", got, w.Code, w.Body.String())
+	}
+}
+
+func TestNewRespEncodeError(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	NewResp(w).Data(make(chan int)).JSON()
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	NewResp(w).Data("ok").JSON()
+	if w.Code != 200 || w.Body.String() != `{"msg":"OK","code":200,"data":"ok"}` {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,84 @@
+package gts
+
+import (
+	"regexp"
+)
+
+// 内置路径参数约束，用法 /order/{id:int}、/doc/{slug:[a-z-]+}
+func defaultConstraints() map[string]func(string) bool {
+
+	return map[string]func(string) bool{
+		"int":    isInt,
+		"uuid":   isUUID,
+		"base58": isBase58,
+	}
+}
+
+// Constraint 注册路径参数约束，需在使用该约束的路由之前注册
+//
+//	route.Constraint("even", func(s string) bool { ... })
+//	route.Get("/num/{n:even}", handler)
+func (p *Router) Constraint(name string, fn func(string) bool) {
+
+	p.constraints[name] = fn
+}
+
+// 约束名优先，否则按正则表达式匹配整个分段
+func constraint(expr string, constraints map[string]func(string) bool) func(string) bool {
+
+	if fn, ok := constraints[expr]; ok {
+		return fn
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic("gts: bad param constraint " + expr + ": " + err.Error())
+	}
+	return re.MatchString
+}
+
+func isInt(s string) bool {
+
+	if s != "" && s[0] == '-' {
+		s = s[1:]
+	}
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// 8-4-4-4-12 十六进制
+func isUUID(s string) bool {
+
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isBase58(s string) bool {
+
+	if s == "" {
+		return false
+	}
+	_, err := Decode(s, BitcoinAlphabet)
+	return err == nil
+}
@@ -1,19 +1,128 @@
 package gts
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"strings"
+
+	"github.com/vmihailenco/msgpack"
 )
 
+const jsonContentType = "application/json; charset=utf-8"
+
 type M map[string]interface{}
 type Context struct {
 	Writer   http.ResponseWriter
 	Request  *http.Request
 	Sessions Session
+	router   *Router
+	params   Params
+	paramBuf [4]Param
+
+	hostParams Params
+	keys       map[interface{}]interface{} //请求内共享的值，见 SetValue
+	aborted    bool
+	after      []func(c *Context)
+}
+
+// Param 获取路径参数，如 /user/:id 或 /files/*path
+func (c *Context) Param(name string) string {
+
+	return c.params.Get(name)
+}
+
+// HostParam 获取 Router.Host 域名规则中 {name} 捕获的值
+func (c *Context) HostParam(name string) string {
+
+	return c.hostParams.Get(name)
+}
+
+// Abort 终止处理链，之后的中间件和处理函数不再执行，外层中间件可通过 IsAborted 判断
+// 不会写入响应，需要时先输出或使用 AbortWithStatus/AbortWithJSON
+func (c *Context) Abort() {
+
+	c.aborted = true
+}
+
+func (c *Context) IsAborted() bool {
+
+	return c.aborted
+}
+
+// AbortWithStatus 写入状态码并终止处理链
+func (c *Context) AbortWithStatus(status int) {
+
+	c.writeHeader(status, "")
+	c.Abort()
+}
+
+// AbortWithJSON 以 JSON 输出 v 并终止处理链
+func (c *Context) AbortWithJSON(status int, v interface{}) {
+
+	c.writeJSON(status, v)
+	c.Abort()
+}
+
+// After 注册请求处理完成后执行的函数，按注册顺序执行，可通过 Status/Size 获取最终的状态码和响应字节数
+//
+//	route.Use(func(next gts.HandlerFunc) gts.HandlerFunc {
+//		return func(req *http.Request, c *gts.Context) {
+//			start := time.Now()
+//			c.After(func(c *gts.Context) {
+//				log.Println(req.URL.Path, c.Status(), c.Size(), time.Since(start))
+//			})
+//			next(req, c)
+//		}
+//	})
+func (c *Context) After(fn func(c *Context)) {
+
+	c.after = append(c.after, fn)
+}
+
+// Status 已写入的状态码，尚未写入时为 200
+func (c *Context) Status() int {
+
+	if w, ok := c.Writer.(ResponseWriter); ok && w.Status() != 0 {
+		return w.Status()
+	}
+	return http.StatusOK
+}
+
+// Size 已写入的响应体字节数
+func (c *Context) Size() int {
+
+	if w, ok := c.Writer.(ResponseWriter); ok {
+		return w.Size()
+	}
+	return 0
+}
+
+// Written 响应是否已提交，Writer 被替换为非 gts.ResponseWriter 时返回 false
+func (c *Context) Written() bool {
+
+	w, ok := c.Writer.(ResponseWriter)
+	return ok && w.Written()
+}
+
+// 响应尚未提交时设置 Content-Type 并写入状态码，已提交时只输出响应体
+func (c *Context) writeHeader(status int, contentType string) {
+
+	if c.Written() {
+		return
+	}
+	if contentType != "" {
+		c.Writer.Header().Set("Content-Type", contentType)
+	}
+	c.Writer.WriteHeader(status)
+}
+
+func (c *Context) runAfter() {
+
+	for _, fn := range c.after {
+		fn(c)
+	}
 }
 
 func (c *Context) ReqValue(params ...string) map[string]interface{} {
@@ -34,8 +143,50 @@ func (c *Context) ReqValue(params ...string) map[string]interface{} {
 
 func (c *Context) Bind(i interface{}) error {
 
+	b, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	return c.jsonCodec().Unmarshal(b, i)
+}
+
+// ShouldBind 按 Content-Type 解析请求体并绑定到 v，与表单共用 form 标签及 Cleaned*/Default* 规则
+// 支持 JSON、XML、MessagePack，其他类型按表单处理；JSON 使用 Router.UseJSON 设置的编解码
+// 请求体先解析为 map[string]interface{} 再交由 BindWith 处理，嵌套对象需对应无标签的结构体字段
+func (c *Context) ShouldBind(v interface{}) error {
+
 	req := c.Request
-	return json.NewDecoder(req.Body).Decode(i)
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	var unmarshal func([]byte) (map[string]interface{}, error)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		unmarshal = func(b []byte) (map[string]interface{}, error) {
+			m := make(map[string]interface{})
+			return m, c.jsonCodec().Unmarshal(b, &m)
+		}
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		unmarshal = decodeXMLMap
+	case mediaType == "application/msgpack" || mediaType == "application/x-msgpack":
+		unmarshal = func(b []byte) (map[string]interface{}, error) {
+			m := make(map[string]interface{})
+			return m, msgpack.Unmarshal(b, &m)
+		}
+	default:
+		return Bind(req, v)
+	}
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	source := make(map[string]interface{})
+	if len(b) > 0 {
+		if source, err = unmarshal(b); err != nil {
+			return err
+		}
+	}
+	return BindWith(source, v)
 }
 
 func (c *Context) FormValue(key, val string) string {
@@ -55,7 +206,7 @@ func (c *Context) CorsHandler() {
 	w := c.Writer
 	w.Header().Set("Access-Control-Allow-Origin", origin)
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 }
@@ -72,104 +223,105 @@ func (c *Context) Session() *Store {
 func (c *Context) Write(status int, b []byte) {
 
 	//print(string(b))
-	c.Writer.WriteHeader(status)
+	c.writeHeader(status, "")
 	c.Writer.Write(b)
 }
 
 func (c *Context) WriteString(s string) {
 
 	//print(s)
-	c.Writer.WriteHeader(200)
+	c.writeHeader(http.StatusOK, "")
 	io.WriteString(c.Writer, s)
 }
 
 func (c *Context) HTML(status int, s string) {
 
 	//print(s)
-	w := c.Writer
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(status)
-	w.Write([]byte(s))
+	c.writeHeader(status, "text/html; charset=utf-8")
+	io.WriteString(c.Writer, s)
 }
 
 func (c *Context) JSON(status int, m map[string]interface{}) {
 
-	w := c.Writer
-	w.Header().Set("Content-Type", "application/Json; charset=utf-8")
-	w.WriteHeader(status)
-	jsonBytes, _ := json.Marshal(m)
-	//print(string(jsonBytes))
-	w.Write(jsonBytes)
+	c.writeJSON(status, m)
 }
 
 func (c *Context) Map(m map[string]interface{}) {
 
-	w := c.Writer
-	w.Header().Set("Content-Type", "application/Json; charset=utf-8")
 	//print(m)
-	json.NewEncoder(w).Encode(m)
+	c.writeJSON(http.StatusOK, m)
 }
 
 func (c *Context) Result(m interface{}) {
 
-	w := c.Writer
-	w.Header().Set("Content-Type", "application/Json; charset=utf-8")
-	json.NewEncoder(w).Encode(m)
+	c.writeJSON(http.StatusOK, m)
 }
-func (c *Context) Msg(code int32, s string) {
+// Msg 输出 {"msg": s, "code": code}，status 为 HTTP 状态码，默认 200
+func (c *Context) Msg(code int32, s string, status ...int) {
 
-	w := c.Writer
-	w.Header().Set("Content-Type", "application/Json; charset=utf-8")
-	str := fmt.Sprintf(`{"code": %d, "msg": "%s"}`, code, s)
-	//print(str)
-	io.WriteString(w, str)
+	c.writeJSON(statusOr(status, http.StatusOK), Result{Code: int(code), Message: s})
 }
-func (c *Context) Err(code int32, s string) {
 
-	w := c.Writer
-	w.Header().Set("Content-Type", "application/Json; charset=utf-8")
-	str := fmt.Sprintf(`{"code": %d, "msg": "%s"}`, code, s)
-	//print(str)
-	io.WriteString(w, str)
+// Err 同 Msg，用于输出错误信息
+func (c *Context) Err(code int32, s string, status ...int) {
+
+	c.writeJSON(statusOr(status, http.StatusOK), Result{Code: int(code), Message: s})
 }
 
 func (c *Context) NotFound() {
 
-	w := c.Writer
-	w.Header().Set("Content-Type", "application/Json; charset=utf-8")
-	io.WriteString(w, `{"code": 404, "msg": "信息不存在"}`)
+	c.writeJSON(http.StatusOK, Result{Code: StatusNotFound, Message: "信息不存在"})
 }
 func (c *Context) NoPermis() {
 
-	w := c.Writer
-	w.Header().Set("Content-Type", "application/Json; charset=utf-8")
-	io.WriteString(w, `{"code": 403, "msg": "没有操作权限"}`)
+	c.writeJSON(http.StatusOK, Result{Code: NotPermis, Message: "没有操作权限"})
 }
 func (c *Context) NoAuth() {
 
-	c.Write(401,[]byte(`{"code": 401, "msg": "auth error"}`))
+	c.writeJSON(http.StatusUnauthorized, Result{Code: NotAuthor, Message: "auth error"})
 }
 func (c *Context) Resp() *RespBuilder {
 
-	return NewResp(c.Writer)
+	b := NewResp(c.Writer)
+	b.ctx = c
+	return b
 }
 func (c *Context) RespData() *RespBuilder {
 
-	return NewResp(c.Writer)
+	return c.Resp()
 }
 
-func (c *Context) OK() {
+// OK 输出处理成功，status 为 HTTP 状态码，默认 200
+func (c *Context) OK(status ...int) {
 
-	w := c.Writer
-	w.Header().Set("Content-Type", "application/Json; charset=utf-8")
-	//w.WriteHeader(http.StatusOK)
-	str := `{"code": 200, "msg": "处理成功"}`
-	//print(str)
-	io.WriteString(w, str)
+	c.writeJSON(statusOr(status, http.StatusOK), Result{Code: StatusOk, Message: "处理成功"})
+}
+
+// 以 JSON 输出 v，序列化失败时交由 Context.Error 处理
+func (c *Context) writeJSON(status int, v interface{}) {
+
+	b, err := c.jsonCodec().Marshal(v)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.writeHeader(status, jsonContentType)
+	c.Writer.Write(b)
+}
+
+func statusOr(status []int, def int) int {
+
+	if len(status) > 0 && status[0] > 0 {
+		return status[0]
+	}
+	return def
 }
 
 func (c *Context) Redirect(url string) {
 
+	if c.Written() {
+		return
+	}
 	w := c.Writer
 	r := c.Request
 	//print("Redirect:" + url)
@@ -182,29 +334,89 @@ func (c *Context) SessionID() (string, bool) {
 	return session.SessionID(c.Request)
 }
 
+// SetValue 保存请求内共享的值，存放在 Context 上，不会复制 Request
+// key 可以是任意可比较的值，建议使用自定义类型避免不同中间件之间冲突
+//
+//	type userKey struct{}
+//	c.SetValue(userKey{}, user)
+func (c *Context) SetValue(key, v interface{}) {
+
+	if c.keys == nil {
+		c.keys = make(map[interface{}]interface{})
+	}
+	c.keys[key] = v
+}
+
+// Value 获取 SetValue 保存的值，不存在时从 Request.Context() 中查找，均不存在返回 nil
+func (c *Context) Value(key interface{}) interface{} {
+
+	v, _ := c.lookup(key)
+	return v
+}
+
+// MustGet 获取 SetValue 保存的值，不存在时 panic，保存的值为 nil 时返回 nil
+func (c *Context) MustGet(key interface{}) interface{} {
+
+	v, ok := c.lookup(key)
+	if !ok {
+		panic(fmt.Sprintf("gts: key %v does not exist", key))
+	}
+	return v
+}
+
+func (c *Context) lookup(key interface{}) (interface{}, bool) {
+
+	if v, ok := c.keys[key]; ok {
+		return v, true
+	}
+	if c.Request == nil {
+		return nil, false
+	}
+	v := c.Request.Context().Value(key)
+	return v, v != nil
+}
+
+// GetInt 获取整数值，不存在或类型不符返回 0
+func (c *Context) GetInt(key interface{}) int {
+
+	switch v := c.Value(key).(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	}
+	return 0
+}
+
+// GetBool 获取布尔值，不存在或类型不符返回 false
+func (c *Context) GetBool(key interface{}) bool {
+
+	v, _ := c.Value(key).(bool)
+	return v
+}
+
 func (c *Context) Set(key string, v map[string]interface{}) {
 
-	ctx := context.WithValue(c.Request.Context(), key, v)
-	c.Request = c.Request.WithContext(ctx)
+	c.SetValue(key, v)
 }
 
+// Get 获取 Set 保存的值，不存在或类型不符返回 nil
 func (c *Context) Get(key string) map[string]interface{} {
 
-	return c.Request.Context().Value(key).(map[string]interface{})
+	v, _ := c.Value(key).(map[string]interface{})
+	return v
 }
 func (c *Context) SetString(key, value string) {
 
-	ctx := context.WithValue(c.Request.Context(), key, value)
-	c.Request = c.Request.WithContext(ctx)
+	c.SetValue(key, value)
 }
 
 func (c *Context) GetString(key string) string {
 
-	v := c.Request.Context().Value(key)
-	if v != nil {
-		return v.(string)
-	}
-	return ""
+	v, _ := c.Value(key).(string)
+	return v
 }
 func (c *Context) SetCookie(key, value string, minute int, args ...bool){
 
@@ -221,7 +433,7 @@ func (c *Context) SetCookie(key, value string, minute int, args ...bool){
 	w := c.Writer
 	http.SetCookie(w, &cookie)
 }
-//使用根域名，通常用于跨域访问cookie，过期时间为cookieExpiresTime
+//使用根域名，通常用于跨域访问cookie，过期时间为Router.SessionExpires设置的时间
 func (c *Context) SetCookieAndDomain(key, value string, args ...bool){
 
 	host := c.Request.Host
@@ -235,7 +447,11 @@ func (c *Context) SetCookieAndDomain(key, value string, args ...bool){
 		httpOnly = args[1]
 	}
 	
-	cookie := http.Cookie{Name: key, Value: value, Path: "/", HttpOnly: httpOnly, SameSite: http.SameSiteNoneMode, Secure: secure, MaxAge:  60*cookieExpiresTime}
+	expires := defaultCookieExpires
+	if c.router != nil {
+		expires = c.router.cookieExpires
+	}
+	cookie := http.Cookie{Name: key, Value: value, Path: "/", HttpOnly: httpOnly, SameSite: http.SameSiteNoneMode, Secure: secure, MaxAge: 60 * expires}
 
 	if strings.Contains(host, "127.0.0.1") {
 		cookie.Domain = "127.0.0.1"
@@ -265,23 +481,26 @@ func (c *Context) GetCookie(key string) (string,error){
 func (c *Context) GetUid() int32 {
 
 	u := c.Get("user_jwt")
-	return u["Uid"].(int32)
+	uid, _ := u["Uid"].(int32)
+	return uid
 }
 func (c *Context) GetName() string {
 
 	u := c.Get("user_jwt")
-	return u["Name"].(string)
+	name, _ := u["Name"].(string)
+	return name
 }
 func (c *Context) GetRid() int32 {
 
 	u := c.Get("user_jwt")
-	return u["Rid"].(int32)
+	rid, _ := u["Rid"].(int32)
+	return rid
 }
 func (c *Context) UidAuth(id int32) {
 
 	u := c.Get("user_jwt")
-	uid := u["Uid"].(int32)
-	if id != uid {
+	uid, ok := u["Uid"].(int32)
+	if !ok || id != uid { //未登录或 user_jwt 类型不符同样视为无权限
 		panic(`{"code": 403, "msg": "没有操作权限"}`)
 	}
 }
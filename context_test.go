@@ -0,0 +1,247 @@
+package gts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAbortAndAfter(t *testing.T) {
+
+	var trace []string
+	var status, size int
+	var aborted bool
+
+	r := New()
+	r.Use(func(next HandlerFunc) HandlerFunc {
+		return func(req *http.Request, c *Context) {
+			c.After(func(c *Context) {
+				status, size = c.Status(), c.Size()
+				trace = append(trace, "after")
+			})
+			next(req, c)
+			aborted = c.IsAborted()
+		}
+	})
+	auth := func(next HandlerFunc) HandlerFunc {
+		return func(req *http.Request, c *Context) {
+			if req.Header.Get("Token") == "" {
+				c.AbortWithJSON(http.StatusUnauthorized, M{"msg": "login"})
+			}
+			next(req, c)
+		}
+	}
+	r.Get("/user", func(req *http.Request, c *Context) {
+		trace = append(trace, "handler")
+		c.WriteString("ok")
+	}, auth)
+	r.Get("/gone", func(req *http.Request, c *Context) {
+		c.AbortWithStatus(http.StatusGone)
+		c.WriteString("body")
+	})
+	r.Get("/quiet", func(req *http.Request, c *Context) {
+		c.Abort()
+	})
+
+	for _, tt := range []struct {
+		url     string
+		token   string
+		code    int
+		body    string
+		trace   string
+		aborted bool
+	}{
+		{"/user", "1", 200, "ok", "handler,after", false},
+		{"/user", "", 401, `{"msg":"login"}`, "after", true},
+		{"/gone", "", 410, "body", "after", true},
+		{"/quiet", "", 200, "", "after", true},
+	} {
+		trace, status, size = nil, 0, 0
+		w := serve(r, http.MethodGet, tt.url, map[string]string{"Token": tt.token})
+		if w.Code != tt.code || strings.TrimSpace(w.Body.String()) != tt.body {
+			t.Errorf("%s token=%q: got %d %q", tt.url, tt.token, w.Code, w.Body.String())
+		}
+		if got := strings.Join(trace, ","); got != tt.trace {
+			t.Errorf("%s token=%q: trace %q, want %q", tt.url, tt.token, got, tt.trace)
+		}
+		if aborted != tt.aborted {
+			t.Errorf("%s token=%q: IsAborted %v", tt.url, tt.token, aborted)
+		}
+		if status != tt.code || size != w.Body.Len() {
+			t.Errorf("%s token=%q: After saw %d/%d, want %d/%d", tt.url, tt.token, status, size, tt.code, w.Body.Len())
+		}
+	}
+}
+
+// 记录底层 WriteHeader 的调用次数
+type headerCounter struct {
+	http.ResponseWriter
+	codes []int
+}
+
+func (w *headerCounter) WriteHeader(code int) {
+
+	w.codes = append(w.codes, code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func TestResponseWriter(t *testing.T) {
+
+	r := New()
+	r.Get("/twice", func(req *http.Request, c *Context) {
+		if c.Written() || c.Status() != 200 || c.Size() != 0 {
+			t.Errorf("before write: %v %d %d", c.Written(), c.Status(), c.Size())
+		}
+		c.Write(http.StatusCreated, []byte("ab"))
+		c.JSON(http.StatusInternalServerError, M{"a": 1})
+		if !c.Written() || c.Status() != http.StatusCreated || c.Size() != len(`ab{"a":1}`) {
+			t.Errorf("after write: %v %d %d", c.Written(), c.Status(), c.Size())
+		}
+	})
+	r.Get("/stream", func(req *http.Request, c *Context) {
+		rw, ok := c.Writer.(ResponseWriter)
+		if !ok {
+			t.Fatal("Writer is not gts.ResponseWriter")
+		}
+		if err := rw.FlushError(); err != nil || !rw.Written() || rw.Status() != 200 {
+			t.Errorf("after Flush: %v %v %d", err, rw.Written(), rw.Status())
+		}
+		if _, _, err := rw.Hijack(); err == nil {
+			t.Error("Hijack on recorder succeeded")
+		}
+		if err := rw.Push("/a.js", nil); err != http.ErrNotSupported {
+			t.Errorf("Push: %v", err)
+		}
+		c.WriteString("data")
+	})
+
+	w := serve(r, http.MethodGet, "/stream", nil)
+	if w.Code != 200 || w.Body.String() != "data" || !w.Flushed {
+		t.Errorf("stream: %d %q flushed=%v", w.Code, w.Body.String(), w.Flushed)
+	}
+
+	w = httptest.NewRecorder()
+	hc := &headerCounter{ResponseWriter: w}
+	r.ServeHTTP(hc, httptest.NewRequest(http.MethodGet, "/twice", nil))
+	if len(hc.codes) != 1 || hc.codes[0] != http.StatusCreated {
+		t.Errorf("WriteHeader calls %v", hc.codes)
+	}
+	if body := w.Body.String(); !strings.HasPrefix(body, "ab") || !strings.Contains(body, `"a":1`) {
+		t.Errorf("body %q", body)
+	}
+
+	//底层不支持 Flush 时 FlushError 返回错误，Flush 不提交响应
+	r.Get("/noflush", func(req *http.Request, c *Context) {
+		rw := c.Writer.(ResponseWriter)
+		rw.Flush()
+		if err := rw.FlushError(); err != http.ErrNotSupported || rw.Written() {
+			t.Errorf("FlushError: %v written=%v", err, rw.Written())
+		}
+		if rw.Unwrap() != http.ResponseWriter(hc) {
+			t.Error("Unwrap should return the original writer")
+		}
+	})
+	hc = &headerCounter{ResponseWriter: httptest.NewRecorder()}
+	r.ServeHTTP(hc, httptest.NewRequest(http.MethodGet, "/noflush", nil))
+}
+
+func TestContextValues(t *testing.T) {
+
+	type ctxKey struct{}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, "from request"))
+	c := &Context{Request: req}
+
+	//未保存任何值时不 panic
+	if c.Get("user_jwt") != nil || c.GetUid() != 0 || c.GetName() != "" || c.GetRid() != 0 || c.GetString("x") != "" {
+		t.Error("missing keys should return zero values")
+	}
+	if c.Value(ctxKey{}) != "from request" || c.MustGet(ctxKey{}) != "from request" {
+		t.Errorf("request context value: %v", c.Value(ctxKey{}))
+	}
+
+	c.SetValue(ctxKey{}, "shadowed")
+	c.SetValue("int", 1)
+	c.SetValue("int32", int32(2))
+	c.SetValue("int64", int64(3))
+	c.SetValue("str", "4")
+	c.SetValue("bool", true)
+	c.SetValue("nil", nil)
+	c.SetString("name", "gts")
+	c.Set("user_jwt", map[string]interface{}{"Uid": int32(7), "Name": "n", "Rid": int32(2)})
+
+	if c.Value(ctxKey{}) != "shadowed" {
+		t.Errorf("SetValue should shadow request context: %v", c.Value(ctxKey{}))
+	}
+	for key, want := range map[string]int{"int": 1, "int32": 2, "int64": 3, "str": 0, "bool": 0, "missing": 0} {
+		if got := c.GetInt(key); got != want {
+			t.Errorf("GetInt(%s) = %d, want %d", key, got, want)
+		}
+	}
+	if !c.GetBool("bool") || c.GetBool("int") || c.GetBool("missing") {
+		t.Error("GetBool")
+	}
+	if c.GetString("name") != "gts" || c.GetString("int") != "" {
+		t.Error("GetString")
+	}
+	if c.GetUid() != 7 || c.GetName() != "n" || c.GetRid() != 2 {
+		t.Errorf("user_jwt: %d %q %d", c.GetUid(), c.GetName(), c.GetRid())
+	}
+	if v := c.MustGet("nil"); v != nil { //显式保存的 nil 不算不存在
+		t.Errorf("MustGet(nil) = %v", v)
+	}
+
+	for _, fn := range []func(){
+		func() { c.MustGet("missing") },
+		func() { c.UidAuth(8) },
+		func() { (&Context{Request: req}).UidAuth(0) }, //未登录
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("want panic")
+				}
+			}()
+			fn()
+		}()
+	}
+	c.UidAuth(7)
+}
+
+// 消息中的引号、换行、尖括号需要正确转义
+func TestMsgAndErr(t *testing.T) {
+
+	msg := "say \"hi\"\n<script>\\"
+	r := New()
+	r.Get("/msg", func(req *http.Request, c *Context) { c.Msg(1, msg) })
+	r.Get("/err", func(req *http.Request, c *Context) { c.Err(500, msg, http.StatusBadRequest) })
+	r.Get("/ok", func(req *http.Request, c *Context) { c.OK(http.StatusCreated) })
+
+	tests := []struct {
+		url    string
+		status int
+		code   int
+		msg    string
+	}{
+		{"/msg", 200, 1, msg},
+		{"/err", 400, 500, msg},
+		{"/ok", 201, StatusOk, "处理成功"},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, tt.url, nil)
+		var res Result
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Errorf("%s: invalid JSON %q: %v", tt.url, w.Body.String(), err)
+			continue
+		}
+		if w.Code != tt.status || res.Code != tt.code || res.Message != tt.msg {
+			t.Errorf("%s: got %d %d %q, want %d %d %q", tt.url, w.Code, res.Code, res.Message, tt.status, tt.code, tt.msg)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != jsonContentType {
+			t.Errorf("%s: Content-Type %q", tt.url, ct)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package gts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticRoute 静态资源挂载的配置，由 Static、StaticFS、StaticDir 返回
+//
+//	route.Static("/assets", "./dist/assets").
+//		Precompressed().
+//		Cache(365*24*time.Hour, ".js", ".css").
+//		Cache(0, ".html")
+type StaticRoute struct {
+	files         http.FileSystem
+	dirs          bool //是否处理目录（index.html 及列表），StaticDir 只提供文件
+	listing       bool //没有 index.html 时列出目录，默认关闭
+	dotfiles      bool //是否提供以 "." 开头的文件和目录，默认隐藏
+	precompressed bool
+	cache         map[string]time.Duration //扩展名 -> 缓存时间，"" 为默认
+	etags         sync.Map                 //文件 -> 强 ETag
+	index         string                   //SPA 模式的首页，为空时不开启
+	exclude       []string                 //SPA 模式下不回退到首页的路径前缀
+	fallbackDots  bool                     //SPA 模式下最后一段带扩展名的路径也回退到首页
+}
+
+// 预压缩文件的扩展名，按优先顺序
+var encodings = []struct{ name, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Listing 目录下没有 index.html 时是否列出文件，默认关闭，StaticDir 不支持
+func (s *StaticRoute) Listing(on bool) *StaticRoute {
+
+	s.listing = on
+	return s
+}
+
+// Dotfiles 是否提供以 "." 开头的文件和目录（如 .git、.env），默认隐藏，返回 404
+func (s *StaticRoute) Dotfiles(on bool) *StaticRoute {
+
+	s.dotfiles = on
+	return s
+}
+
+// Precompressed 客户端支持时优先返回同目录下的 .br/.gz 文件
+func (s *StaticRoute) Precompressed() *StaticRoute {
+
+	s.precompressed = true
+	return s
+}
+
+// Cache 设置 Cache-Control 和 Expires，exts 为空时作用于该前缀下的全部文件
+// maxAge <= 0 时为 no-cache，每次都需用 ETag 验证
+func (s *StaticRoute) Cache(maxAge time.Duration, exts ...string) *StaticRoute {
+
+	if s.cache == nil {
+		s.cache = make(map[string]time.Duration)
+	}
+	if len(exts) == 0 {
+		exts = []string{""}
+	}
+	for _, ext := range exts {
+		s.cache[strings.ToLower(ext)] = maxAge
+	}
+	return s
+}
+
+// SPA 单页应用模式，前缀下不存在的路径返回 index（默认 /index.html），由前端路由处理
+// exclude 为不回退的路径前缀（完整请求路径），如 "/api"，这些路径仍返回 404；
+// 只回退 Accept 接受 text/html 的请求，最后一段带扩展名的路径（如 /assets/app.3f2a.js）默认不回退，见 FallbackDots
+//
+//	route.Static("/", "./dist").SPA("", "/api")
+func (s *StaticRoute) SPA(index string, exclude ...string) *StaticRoute {
+
+	if index == "" {
+		index = "/index.html"
+	}
+	s.index = "/" + strings.TrimPrefix(index, "/")
+	s.exclude = exclude
+	return s
+}
+
+// FallbackDots SPA 模式下最后一段带扩展名的路径是否也返回首页，如前端路由 /users/john.doe，默认关闭
+func (s *StaticRoute) FallbackDots(on bool) *StaticRoute {
+
+	s.fallbackDots = on
+	return s
+}
+
+// fallback 文件不存在时按 SPA 模式返回首页
+func (s *StaticRoute) fallback(w http.ResponseWriter, r *http.Request) bool {
+
+	if s.index == "" {
+		return false
+	}
+	for _, ex := range s.exclude {
+		ex = strings.TrimSuffix(ex, "/")
+		if r.URL.Path == ex || strings.HasPrefix(r.URL.Path, ex+"/") {
+			return false
+		}
+	}
+	if !s.fallbackDots && strings.Contains(path.Base(r.URL.Path), ".") {
+		return false
+	}
+	if accept := r.Header.Get("Accept"); accept != "" && acceptQuality(accept, "text/html") <= 0 {
+		return false //非页面请求，如 fetch/XHR 或脚本、图片
+	}
+	return s.serve(w, r, s.index)
+}
+
+// serve 输出 name 对应的文件，文件不存在时返回 false
+func (s *StaticRoute) serve(w http.ResponseWriter, r *http.Request, name string) bool {
+
+	f, err := s.open(name)
+	if err != nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return false
+	}
+	if info.IsDir() {
+		f.Close()
+		if !s.dirs {
+			return false
+		}
+		if !strings.HasSuffix(name, "/") { //同 http.FileServer，目录补全末尾的 "/"
+			if !s.listing && !s.exists(name+"/index.html") {
+				return false
+			}
+			redirect(w, r, r.URL.EscapedPath()+"/")
+			return true
+		}
+		if s.serve(w, r, name+"index.html") {
+			return true
+		}
+		if !s.listing {
+			return false
+		}
+		http.FileServer(openFunc(s.open)).ServeHTTP(w, stripPath(r, name))
+		return true
+	}
+
+	h := w.Header()
+	if s.precompressed {
+		h.Add("Vary", "Accept-Encoding")
+		accept := r.Header.Get("Accept-Encoding")
+		for _, enc := range encodings {
+			if !acceptsEncoding(accept, enc.name) {
+				continue
+			}
+			cf, err := s.open(name + enc.ext)
+			if err != nil {
+				continue
+			}
+			ci, err := cf.Stat()
+			if err != nil || ci.IsDir() {
+				cf.Close()
+				continue
+			}
+			f.Close()
+			f, info = cf, ci
+			ctype := mime.TypeByExtension(path.Ext(name))
+			if ctype == "" {
+				ctype = "application/octet-stream"
+			}
+			h.Set("Content-Type", ctype)
+			h.Set("Content-Encoding", enc.name)
+			break
+		}
+	}
+	defer f.Close()
+
+	if maxAge, ok := s.maxAge(name); ok {
+		if maxAge > 0 {
+			h.Set("Cache-Control", "public, max-age="+strconv.FormatInt(int64(maxAge/time.Second), 10))
+			h.Set("Expires", time.Now().Add(maxAge).UTC().Format(http.TimeFormat))
+		} else {
+			h.Set("Cache-Control", "no-cache")
+		}
+	}
+
+	if etag := s.etag(name+h.Get("Content-Encoding"), info.ModTime(), info.Size(), f); etag != "" {
+		h.Set("ETag", etag)
+	}
+	//ServeContent 根据 ETag 处理 If-None-Match/If-Match，命中时返回 304
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	return true
+}
+
+// open 打开文件，隐藏 dotfiles，目录列表中同样去掉
+func (s *StaticRoute) open(name string) (http.File, error) {
+
+	if !s.dotfiles && hasDotSegment(path.Clean("/"+name)) {
+		return nil, os.ErrNotExist
+	}
+	f, err := s.files.Open(name)
+	if err != nil || s.dotfiles {
+		return f, err
+	}
+	return hiddenDir{f}, nil
+}
+
+func (s *StaticRoute) exists(name string) bool {
+
+	f, err := s.open(name)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+func (s *StaticRoute) maxAge(name string) (time.Duration, bool) {
+
+	if v, ok := s.cache[strings.ToLower(path.Ext(name))]; ok {
+		return v, true
+	}
+	v, ok := s.cache[""]
+	return v, ok
+}
+
+type etagEntry struct {
+	mod  time.Time
+	size int64
+	tag  string
+}
+
+// etag 按文件内容计算强 ETag，文件修改时间和大小不变时使用缓存
+func (s *StaticRoute) etag(key string, mod time.Time, size int64, f http.File) string {
+
+	if v, ok := s.etags.Load(key); ok {
+		e := v.(etagEntry)
+		if e.mod.Equal(mod) && e.size == size {
+			return e.tag
+		}
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return ""
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	tag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	s.etags.Store(key, etagEntry{mod: mod, size: size, tag: tag})
+	return tag
+}
+
+// acceptsEncoding 判断 Accept-Encoding 是否接受 enc，q=0 视为不接受
+func acceptsEncoding(header, enc string) bool {
+
+	for _, part := range strings.Split(header, ",") {
+		name, params := part, ""
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			name, params = part[:i], part[i+1:]
+		}
+		if !strings.EqualFold(strings.TrimSpace(name), enc) {
+			continue
+		}
+		params = strings.TrimSpace(params)
+		if strings.HasPrefix(params, "q=") {
+			q, err := strconv.ParseFloat(params[2:], 64)
+			return err == nil && q > 0
+		}
+		return true
+	}
+	return false
+}
+
+type openFunc func(name string) (http.File, error)
+
+func (f openFunc) Open(name string) (http.File, error) {
+
+	return f(name)
+}
+
+// 目录列表中去掉以 "." 开头的文件
+type hiddenDir struct {
+	http.File
+}
+
+func (d hiddenDir) Readdir(count int) ([]os.FileInfo, error) {
+
+	list, err := d.File.Readdir(count)
+	n := 0
+	for _, info := range list {
+		if !strings.HasPrefix(info.Name(), ".") {
+			list[n] = info
+			n++
+		}
+	}
+	return list[:n], err
+}
+
+func hasDotSegment(name string) bool {
+
+	for _, seg := range strings.Split(name, "/") {
+		if strings.HasPrefix(seg, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// rootDir 本地目录，请求只能访问根目录内的文件，
+// 路径经过清理，符号链接解析后指向根目录之外时视为不存在
+type rootDir string
+
+func (d rootDir) Open(name string) (http.File, error) {
+
+	root, err := filepath.Abs(string(d))
+	if err != nil {
+		return nil, err
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(root, filepath.FromSlash(path.Clean("/"+name)))
+	real, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return nil, err
+	}
+	if real != root && !strings.HasPrefix(real, root+string(filepath.Separator)) {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(real)
+}
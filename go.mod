@@ -1,3 +1,8 @@
 module github.com/gkyh/gts
 
-go 1.14
+go 1.16
+
+require (
+	github.com/gomodule/redigo v1.8.9
+	github.com/vmihailenco/msgpack v4.0.4+incompatible
+)
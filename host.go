@@ -0,0 +1,111 @@
+package gts
+
+import (
+	"net"
+	"strings"
+)
+
+// 按域名区分的路由表
+type hostTable struct {
+	pattern string
+	labels  []string
+	wild    bool //含 {name} 或 * 标签
+	*table
+}
+
+// Host 按域名注册路由，返回子路由并传给 h（h 可为 nil）
+// pattern 支持精确域名 api.example.com 和通配 {tenant}.example.com、*.example.com，
+// {name} 捕获的值通过 Context.HostParam 获取；精确域名优先，未匹配任何域名时使用默认路由表
+func (p *Router) Host(pattern string, h func(r *Router)) *Router {
+
+	pattern = strings.ToLower(pattern)
+
+	var ht *hostTable
+	for _, v := range *p.hosts {
+		if v.pattern == pattern {
+			ht = v
+			break
+		}
+	}
+	if ht == nil {
+		ht = &hostTable{pattern: pattern, labels: strings.Split(pattern, "."), table: newTable()}
+		for _, l := range ht.labels {
+			if l == "*" || isHostParam(l) {
+				ht.wild = true
+			}
+		}
+		p.addHost(ht)
+	}
+
+	c := p.child("", nil)
+	c.table = ht.table
+	c.host = pattern
+	if h != nil {
+		h(c)
+	}
+	return c
+}
+
+// 精确域名排在通配域名之前，同类按注册顺序
+func (p *Router) addHost(ht *hostTable) {
+
+	hosts := *p.hosts
+	i := len(hosts)
+	if !ht.wild {
+		for i = 0; i < len(hosts) && !hosts[i].wild; i++ {
+		}
+	}
+	hosts = append(hosts, nil)
+	copy(hosts[i+1:], hosts[i:])
+	hosts[i] = ht
+	*p.hosts = hosts
+}
+
+// 返回匹配请求域名的路由表，并把捕获的域名参数写入 ctx
+func (p *Router) matchHost(host string, ctx *Context) *table {
+
+	if len(*p.hosts) == 0 {
+		return nil
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	for _, ht := range *p.hosts {
+		if ht.match(host, ctx) {
+			return ht.table
+		}
+	}
+	return nil
+}
+
+func (ht *hostTable) match(host string, ctx *Context) bool {
+
+	if !ht.wild {
+		return host == ht.pattern
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) != len(ht.labels) {
+		return false
+	}
+	for i, l := range ht.labels {
+		if l != "*" && !isHostParam(l) && l != labels[i] {
+			return false
+		}
+	}
+
+	ctx.hostParams = ctx.hostParams[:0]
+	for i, l := range ht.labels {
+		if isHostParam(l) {
+			ctx.hostParams = append(ctx.hostParams, Param{Key: l[1 : len(l)-1], Value: labels[i]})
+		}
+	}
+	return true
+}
+
+func isHostParam(label string) bool {
+
+	return len(label) > 2 && label[0] == '{' && label[len(label)-1] == '}'
+}
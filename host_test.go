@@ -0,0 +1,72 @@
+package gts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveHost(r *Router, host, url string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	req.Host = host
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHost(t *testing.T) {
+
+	reply := func(s string) HandlerFunc {
+		return func(req *http.Request, c *Context) {
+			c.WriteString(s + c.HostParam("tenant") + c.HostParam("region"))
+		}
+	}
+
+	r := New()
+	r.Get("/", reply("default"))
+	r.Host("{tenant}.example.com", func(h *Router) {
+		h.Get("/", reply("tenant:"))
+	})
+	r.Host("*.{region}.example.com", nil).Get("/", reply("region:"))
+	r.Host("API.example.com", func(h *Router) { //精确域名后注册也优先于通配
+		h.Get("/", reply("api"))
+	})
+
+	var order []string
+	for _, ht := range *r.hosts {
+		order = append(order, ht.pattern)
+	}
+	if got := strings.Join(order, " "); got != "api.example.com {tenant}.example.com *.{region}.example.com" {
+		t.Errorf("host order %q", got)
+	}
+
+	tests := []struct {
+		host   string
+		status int
+		body   string
+	}{
+		{"api.example.com", 200, "api"},
+		{"API.Example.com:8080", 200, "api"},
+		{"api.example.com.", 200, "api"},
+		{"acme.example.com", 200, "tenant:acme"},
+		{"acme.example.com.:443", 200, "tenant:acme"},
+		{"a.eu.example.com", 200, "region:eu"},
+		{"a.b.eu.example.com", 200, "default"}, //标签数不一致
+		{"example.com", 200, "default"},
+		{"other.org", 200, "default"},
+		{"[::1]:8080", 200, "default"},
+	}
+	for _, tt := range tests {
+		w := serveHost(r, tt.host, "/")
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q, want %d %q", tt.host, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+
+	//匹配到域名后只查找该域名的路由表
+	if w := serveHost(r, "api.example.com", "/missing"); w.Code != 404 {
+		t.Errorf("api.example.com/missing: got %d", w.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package gts
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type mountKey struct{}
+
+// Mount 在 prefix 下挂载任意 http.Handler，如其他 mux、httputil.ReverseProxy
+// 请求路径去掉 prefix 后交给 h，全局、分组中间件及 f 在外层执行；原始路径通过 OriginalPath 获取
+//
+//	route.Mount("/legacy", legacyMux)  // /legacy/users => legacyMux 收到 /users
+func (p *Router) Mount(prefix string, h http.Handler, f ...HandlerFun) {
+
+	prefix = strings.TrimSuffix(p.base+prefix, "/")
+
+	var adapted HandlerFunc = func(r *http.Request, ctx *Context) {
+
+		r = ctx.Request
+		c := r.Context()
+		if _, ok := c.Value(mountKey{}).(string); !ok { //嵌套挂载时保留最外层的原始路径
+			c = context.WithValue(c, mountKey{}, r.URL.Path)
+		}
+		r2 := r.WithContext(c)
+		u := *r.URL
+		u.Path = strings.TrimPrefix(u.Path, prefix)
+		if u.Path == "" || u.Path[0] != '/' {
+			u.Path = "/" + u.Path
+		}
+		if u.RawPath != "" {
+			rp := strings.TrimPrefix(u.RawPath, prefix)
+			if len(rp) == len(u.RawPath) {
+				u.RawPath = ""
+			} else if rp == "" || rp[0] != '/' {
+				u.RawPath = "/" + rp
+			} else {
+				u.RawPath = rp
+			}
+		}
+		r2.URL = &u
+		h.ServeHTTP(ctx.Writer, r2)
+	}
+
+	if len(f) > 0 {
+		adapted = middleware(f, adapted)
+	}
+	p.handlers.addStatic(prefix).h = middleware(p.mws, adapted)
+
+	name := fmt.Sprintf("%T", h)
+	p.print(prefix, " ==> ", name)
+	p.addRouteInfo(AnyMethod, prefix+"/*", name, append(p.mws[:len(p.mws):len(p.mws)], f...))
+}
+
+// OriginalPath 返回 Mount 挂载的 handler 收到的请求在去掉前缀之前的路径，嵌套挂载时为最外层的路径，非挂载请求返回 r.URL.Path
+func OriginalPath(r *http.Request) string {
+
+	if v, ok := r.Context().Value(mountKey{}).(string); ok {
+		return v
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,113 @@
+package gts
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// RedirectTrailingSlash 路由只注册了另一种末尾斜杠形式时（/user 与 /user/）重定向过去，默认开启
+func (p *Router) RedirectTrailingSlash(on bool) {
+
+	p.trailingSlash = on
+}
+
+// RedirectCleanPath 路径含 //、. 或 .. 时重定向到 path.Clean 后的路径，默认开启
+func (p *Router) RedirectCleanPath(on bool) {
+
+	p.cleanPath = on
+}
+
+// RedirectCaseInsensitive 路径大小写与已注册路由不一致时重定向到已注册的写法，默认关闭
+func (p *Router) RedirectCaseInsensitive(on bool) {
+
+	p.caseInsensitive = on
+}
+
+// 查找可重定向的规范路径，没有时返回 ""
+func (p *Router) canonical(t *table, method, url string) string {
+
+	root := t.trees[method]
+	if root == nil && method == http.MethodHead {
+		root = t.trees[http.MethodGet]
+	}
+	if root == nil {
+		return ""
+	}
+
+	var buf [4]Param
+	if p.trailingSlash && url != "/" {
+		alt := url + "/"
+		if strings.HasSuffix(url, "/") {
+			alt = url[:len(url)-1]
+		}
+		ps := Params(buf[:0])
+		if root.find(alt, &ps) != nil {
+			return alt
+		}
+	}
+
+	if p.caseInsensitive {
+		if fixed, ok := root.findCase(url, nil); ok {
+			return string(fixed)
+		}
+		if p.trailingSlash && url != "/" {
+			alt := url + "/"
+			if strings.HasSuffix(url, "/") {
+				alt = url[:len(url)-1]
+			}
+			if fixed, ok := root.findCase(alt, nil); ok {
+				return string(fixed)
+			}
+		}
+	}
+	return ""
+}
+
+// GET/HEAD 使用 301，其他方法使用 308 保留请求方法和请求体
+// to 为转义后的路径，未转义的路径先经 escapePath 处理，避免 "\"、"?" 等字符改变跳转目标
+func redirect(w http.ResponseWriter, r *http.Request, to string) {
+
+	code := http.StatusMovedPermanently
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		code = http.StatusPermanentRedirect
+	}
+	if r.URL.RawQuery != "" {
+		to += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, to, code)
+}
+
+// 转义路径中的 "?"、"\" 等字符，用于 Location
+func escapePath(p string) string {
+
+	return (&url.URL{Path: p}).EscapedPath()
+}
+
+// 规范化请求的转义路径，%2F 等编码保持不变；返回 "" 表示路径已规范
+func cleanEscapedPath(u *url.URL) string {
+
+	ep := u.EscapedPath()
+	dots := strings.NewReplacer("%2E", ".", "%2e", ".").Replace(ep) //%2E 与 "." 等价
+	if cp := cleanPath(dots); cp != dots {
+		return cp
+	}
+	return ""
+}
+
+// 同 path.Clean，但保留末尾的 "/"
+func cleanPath(p string) string {
+
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
@@ -17,6 +17,7 @@ type RedisSession struct {
 	mMaxLifeTime int64 //垃圾回收时间
 	mCookieTime  int64
 	mSecure      bool
+	pool         *RedisPool
 	//mSessions    map[string]*Provider //保存session的指针[sessionID] = session
 }
 
@@ -64,15 +65,29 @@ func newRedisPool(server, password string, database ...int) (*RedisPool, error)
 	return &RedisPool{pool}, nil
 }
 
+//使用最近一次 NewRedisSession 创建的连接池
 func SetEx(key string, value []byte, time int32) error {
 
+	return rp.SetEx(key, value, time)
+}
+func DelEx(key string) error {
+
+	return rp.DelEx(key)
+}
+func GetEx(key string) ([]byte, error) {
+
+	return rp.GetEx(key)
+}
+
+func (rp *RedisPool) SetEx(key string, value []byte, time int32) error {
+
 	c := rp.Pool.Get()
 	defer c.Close()
 
 	_, err := c.Do("SET", key, value, "EX", time)
 	return err
 }
-func DelEx(key string) error {
+func (rp *RedisPool) DelEx(key string) error {
 
 	c := rp.Pool.Get()
 	defer c.Close()
@@ -80,7 +95,7 @@ func DelEx(key string) error {
 	_, err := c.Do("DEL", key)
 	return err
 }
-func GetEx(key string) ([]byte, error) {
+func (rp *RedisPool) GetEx(key string) ([]byte, error) {
 
 	c := rp.Pool.Get()
 	defer c.Close()
@@ -90,14 +105,13 @@ func GetEx(key string) ([]byte, error) {
 //创建会话管理器(cookieName:在浏览器中cookie的名字;maxLifeTime:最长生命周期)
 func NewRedisSession(cookieName string, maxLifeTime, cookieTime int64,secure bool, RedisHost, RedisPwd string, database ...int) *RedisSession {
 
-	var err error
-
-	rp, err = newRedisPool(RedisHost, RedisPwd, database...)
+	pool, err := newRedisPool(RedisHost, RedisPwd, database...)
 	if err != nil {
 		panic(err)
 	}
+	rp = pool
 
-	ses := &RedisSession{mCookieName: cookieName, mMaxLifeTime: maxLifeTime, mCookieTime: cookieTime, mSecure: secure}
+	ses := &RedisSession{mCookieName: cookieName, mMaxLifeTime: maxLifeTime, mCookieTime: cookieTime, mSecure: secure, pool: pool}
 
 	return ses
 }
@@ -113,7 +127,7 @@ func (ses *RedisSession) New(w http.ResponseWriter) string {
 	b, err := msgpack.Marshal(mValues)
 	if err == nil {
 
-		SetEx(ses.mCookieName+newSessionID, b, int32(ses.mMaxLifeTime))
+		ses.pool.SetEx(ses.mCookieName+newSessionID, b, int32(ses.mMaxLifeTime))
 	}
 
 	//让浏览器cookie设置过期时间
@@ -130,7 +144,7 @@ func (ses *RedisSession) Del(w http.ResponseWriter, r *http.Request) {
 		return
 	} else {
 
-		DelEx(ses.mCookieName + cookie.Value)
+		ses.pool.DelEx(ses.mCookieName + cookie.Value)
 
 		//让浏览器cookie立刻过期
 		expiration := time.Now()
@@ -142,13 +156,13 @@ func (ses *RedisSession) Del(w http.ResponseWriter, r *http.Request) {
 //结束session
 func (ses *RedisSession) Remove(sessionID string) {
 
-	DelEx(ses.mCookieName + sessionID)
+	ses.pool.DelEx(ses.mCookieName + sessionID)
 }
 
 //设置session里面的值
 func (ses *RedisSession) SetVal(sessionID string, key string, value interface{}) error {
 
-	b, err := GetEx(ses.mCookieName + sessionID)
+	b, err := ses.pool.GetEx(ses.mCookieName + sessionID)
 	if err != nil {
 
 		return err
@@ -168,14 +182,14 @@ func (ses *RedisSession) SetVal(sessionID string, key string, value interface{})
 		return err
 	}
 
-	return SetEx(ses.mCookieName+sessionID, m, int32(ses.mMaxLifeTime))
+	return ses.pool.SetEx(ses.mCookieName+sessionID, m, int32(ses.mMaxLifeTime))
 
 }
 
 //得到session里面的值
 func (ses *RedisSession) GetVal(sessionID string, key string) interface{} {
 
-	b, err := GetEx(ses.mCookieName + sessionID)
+	b, err := ses.pool.GetEx(ses.mCookieName + sessionID)
 	if err != nil {
 
 		return nil
@@ -187,7 +201,7 @@ func (ses *RedisSession) GetVal(sessionID string, key string) interface{} {
 		return nil
 	}
 
-	SetEx(ses.mCookieName+sessionID, b, int32(ses.mMaxLifeTime))
+	ses.pool.SetEx(ses.mCookieName+sessionID, b, int32(ses.mMaxLifeTime))
 
 	return out[key]
 }
@@ -207,13 +221,13 @@ func (ses *RedisSession) SessionID(r *http.Request) (string, bool) {
 
 	sessionID := cookie.Value
 
-	b, err := GetEx(ses.mCookieName + sessionID)
+	b, err := ses.pool.GetEx(ses.mCookieName + sessionID)
 	if err != nil {
 
 		return "", false
 	}
 
-	SetEx(ses.mCookieName+sessionID, b, int32(ses.mMaxLifeTime))
+	ses.pool.SetEx(ses.mCookieName+sessionID, b, int32(ses.mMaxLifeTime))
 
 	return sessionID, true
 }
@@ -228,7 +242,7 @@ func (ses *RedisSession) Set(r *http.Request, key string, value interface{}) boo
 
 	sessionID := cookie.Value
 
-	b, err := GetEx(ses.mCookieName + sessionID)
+	b, err := ses.pool.GetEx(ses.mCookieName + sessionID)
 	if err != nil {
 
 		return false
@@ -248,7 +262,7 @@ func (ses *RedisSession) Set(r *http.Request, key string, value interface{}) boo
 		return false
 	}
 
-	err = SetEx(ses.mCookieName+sessionID, m, int32(ses.mMaxLifeTime))
+	err = ses.pool.SetEx(ses.mCookieName+sessionID, m, int32(ses.mMaxLifeTime))
 	if err != nil {
 
 		return false
@@ -266,7 +280,7 @@ func (ses *RedisSession) Get(r *http.Request, key string) (interface{}, bool) {
 
 	sessionID := cookie.Value
 
-	b, err := GetEx(ses.mCookieName + sessionID)
+	b, err := ses.pool.GetEx(ses.mCookieName + sessionID)
 	if err != nil {
 
 		return nil, false
@@ -278,7 +292,7 @@ func (ses *RedisSession) Get(r *http.Request, key string) (interface{}, bool) {
 		return nil, false
 	}
 
-	SetEx(ses.mCookieName+sessionID, b, int32(ses.mMaxLifeTime))
+	ses.pool.SetEx(ses.mCookieName+sessionID, b, int32(ses.mMaxLifeTime))
 
 	if out[key] == nil {
 
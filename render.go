@@ -0,0 +1,181 @@
+package gts
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack"
+)
+
+const (
+	xmlContentType     = "application/xml; charset=utf-8"
+	msgpackContentType = "application/msgpack"
+	textContentType    = "text/plain; charset=utf-8"
+)
+
+// 内容协商支持的格式，q 相同时按此顺序优先
+var offers = []struct {
+	types       []string
+	contentType string
+	encode      func(c *Context, v interface{}) ([]byte, error)
+}{
+	{[]string{"application/json"}, jsonContentType, func(c *Context, v interface{}) ([]byte, error) { return c.jsonCodec().Marshal(v) }},
+	{[]string{"application/xml", "text/xml"}, xmlContentType, encodeXML},
+	{[]string{"application/msgpack", "application/x-msgpack"}, msgpackContentType, func(c *Context, v interface{}) ([]byte, error) { return msgpack.Marshal(v) }},
+	{[]string{"text/plain"}, textContentType, encodeText},
+}
+
+// Negotiate 按 Accept 请求头选择 JSON、XML、MessagePack 或纯文本输出 data
+// 未带 Accept 时使用 JSON；按 q 值依次尝试可接受的格式，data 的类型无法以该格式表示时（如 map 之于 XML）尝试下一个，
+// 其他序列化错误交由 Context.Error 处理，都不可接受时返回 406
+func (c *Context) Negotiate(status int, data interface{}) {
+
+	accept := c.Request.Header.Get("Accept")
+	if accept == "" {
+		c.writeJSON(status, data)
+		return
+	}
+
+	type candidate struct {
+		i int
+		q float64
+	}
+	var candidates []candidate
+	for i, o := range offers {
+		q := 0.0
+		for _, t := range o.types {
+			if v := acceptQuality(accept, t); v > q {
+				q = v
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{i, q})
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].q > candidates[b].q })
+
+	for _, cd := range candidates {
+		i := cd.i
+		b, err := offers[i].encode(c, data)
+		if err != nil {
+			if unrepresentable(err) {
+				continue
+			}
+			c.Error(err)
+			return
+		}
+		c.writeHeader(status, offers[i].contentType)
+		if offers[i].contentType == xmlContentType {
+			c.Writer.Write([]byte(xml.Header))
+		}
+		c.Writer.Write(b)
+		return
+	}
+
+	c.writeHeader(http.StatusNotAcceptable, textContentType)
+	c.Writer.Write([]byte(http.StatusText(http.StatusNotAcceptable)))
+}
+
+// XML 以 XML 输出 v，序列化失败时交由 Context.Error 处理
+func (c *Context) XML(status int, v interface{}) {
+
+	b, err := encodeXML(c, v)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.writeHeader(status, xmlContentType)
+	c.Writer.Write([]byte(xml.Header))
+	c.Writer.Write(b)
+}
+
+// MsgPack 以 MessagePack 输出 v，序列化失败时交由 Context.Error 处理
+func (c *Context) MsgPack(status int, v interface{}) {
+
+	b, err := msgpack.Marshal(v)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.writeHeader(status, msgpackContentType)
+	c.Writer.Write(b)
+}
+
+func encodeXML(c *Context, v interface{}) ([]byte, error) {
+
+	return xml.Marshal(v)
+}
+
+var errNotText = errors.New("gts: value has no plain text form")
+
+// data 的类型不能以该格式表示，可以换用其他格式
+func unrepresentable(err error) bool {
+
+	var xe *xml.UnsupportedTypeError
+	return err == errNotText || errors.As(err, &xe)
+}
+
+// encodeText 纯文本只输出 string、[]byte、fmt.Stringer 及 Result/Resource 的 Message，其他类型视为不可接受
+func encodeText(c *Context, v interface{}) ([]byte, error) {
+
+	switch t := v.(type) {
+	case string:
+		return []byte(t), nil
+	case []byte:
+		return t, nil
+	case fmt.Stringer:
+		return []byte(t.String()), nil
+	case Result:
+		return []byte(t.Message), nil
+	case *Result:
+		if t != nil {
+			return []byte(t.Message), nil
+		}
+	case Resource:
+		return []byte(t.Message), nil
+	case *Resource:
+		if t != nil {
+			return []byte(t.Message), nil
+		}
+	}
+	return nil, errNotText
+}
+
+// acceptQuality 返回 Accept 中 mediaType 的 q 值，按最具体的匹配项计算，不可接受时为 0
+func acceptQuality(accept, mediaType string) float64 {
+
+	q, level := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		l := -1
+		switch {
+		case t == mediaType:
+			l = 2
+		case t == "*/*":
+			l = 0
+		case strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]):
+			l = 1
+		}
+		if l <= level {
+			continue
+		}
+
+		level, q = l, 1
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+	}
+	return q
+}
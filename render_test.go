@@ -0,0 +1,125 @@
+package gts
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack"
+)
+
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+func negotiate(t *testing.T, accept string, h HandlerFunc) *httptest.ResponseRecorder {
+
+	t.Helper()
+
+	r := New()
+	r.Get("/", h)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestNegotiate(t *testing.T) {
+
+	result := Result{Code: 200, Message: "OK", Data: "x"}
+	tests := []struct {
+		name, accept string
+		data         interface{}
+		status       int
+		contentType  string
+		body         string
+	}{
+		{"no accept", "", result, 200, jsonContentType, `{"msg":"OK","code":200,"data":"x"}`},
+		{"xml", "application/xml", result, 200, xmlContentType, `<Result><msg>OK</msg><code>200</code><data>x</data></Result>`},
+		{"browser struct", browserAccept, result, 200, xmlContentType, `<msg>OK</msg>`},
+		{"browser map falls back to json", browserAccept, M{"a": 1}, 200, jsonContentType, `{"a":1}`},
+		{"xml only map", "application/xml", M{"a": 1}, 406, textContentType, "Not Acceptable"},
+		{"text result", "text/plain", result, 200, textContentType, "OK"},
+		{"text string", "text/plain", "hello", 200, textContentType, "hello"},
+		{"text map", "text/plain", M{"a": 1}, 406, textContentType, "Not Acceptable"},
+		{"q order", "text/plain;q=0.5, application/json;q=0.4", result, 200, textContentType, "OK"},
+		{"json refused", "application/json;q=0, */*", result, 200, xmlContentType, `<msg>OK</msg>`},
+		{"unacceptable", "image/png", result, 406, textContentType, "Not Acceptable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			w := negotiate(t, tt.accept, func(r *http.Request, c *Context) {
+				c.Negotiate(200, tt.data)
+			})
+			if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType || !strings.Contains(w.Body.String(), tt.body) {
+				t.Fatalf("got %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNegotiateMsgPack(t *testing.T) {
+
+	w := negotiate(t, "application/msgpack", func(r *http.Request, c *Context) {
+		c.Negotiate(201, M{"a": "b"})
+	})
+	if w.Code != 201 || w.Header().Get("Content-Type") != msgpackContentType {
+		t.Fatalf("got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var m map[string]interface{}
+	if err := msgpack.Unmarshal(w.Body.Bytes(), &m); err != nil || m["a"] != "b" {
+		t.Fatalf("decode: %v %v", m, err)
+	}
+}
+
+func TestRespBuilderNegotiate(t *testing.T) {
+
+	w := negotiate(t, browserAccept, func(r *http.Request, c *Context) {
+		c.Resp().Data(M{"x": 1}).Negotiate()
+	})
+	if w.Code != 200 || w.Header().Get("Content-Type") != jsonContentType {
+		t.Fatalf("got %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+// 能以文本输出，但 JSON、XML 序列化出错
+type badEncoding struct{}
+
+func (badEncoding) String() string { return "text" }
+
+func (badEncoding) MarshalJSON() ([]byte, error) { return nil, errors.New("json failed") }
+
+func (badEncoding) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return errors.New("xml failed")
+}
+
+// 只有类型无法表示时才换用下一个格式，其他序列化错误交由 Context.Error 处理
+func TestNegotiateEncodeError(t *testing.T) {
+
+	for _, tt := range []struct {
+		accept string
+		data   interface{}
+		status int
+		body   string
+	}{
+		{"application/json", M{"ch": make(chan int)}, 500, ""},
+		{"application/json, text/plain;q=0.5", badEncoding{}, 500, ""},
+		{"application/xml, text/plain;q=0.5", badEncoding{}, 500, ""},
+		{"application/xml, text/plain;q=0.5", M{"a": 1}, 406, ""}, //map 不能以 XML、文本表示
+		{"application/xml, application/json;q=0.5", M{"a": 1}, 200, `{"a":1}`},
+		{"text/plain, application/json;q=0.5", M{"a": 1}, 200, `{"a":1}`},
+	} {
+		data := tt.data
+		w := negotiate(t, tt.accept, func(r *http.Request, c *Context) {
+			c.Negotiate(200, data)
+		})
+		if w.Code != tt.status || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s %T: got %d %q, want %d", tt.accept, tt.data, w.Code, w.Body.String(), tt.status)
+		}
+	}
+}
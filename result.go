@@ -1,7 +1,6 @@
 package gts
 
 import (
-	"encoding/json"
 	"net/http"
 )
 
@@ -24,24 +23,24 @@ const BadGateway int = 502 //外部异常，通常为请求第三方
 const Unavilable int = 503 //请求未能应答
 
 type Result struct {
-	Message string      `json:"msg"`
-	Code    int         `json:"code"` // 200 means success, other means fail
-	Data    interface{} `json:"data"`
+	Message string      `json:"msg" xml:"msg" msgpack:"msg"`
+	Code    int         `json:"code" xml:"code" msgpack:"code"` // 200 means success, other means fail
+	Data    interface{} `json:"data" xml:"data" msgpack:"data"`
 }
 
 type Resource struct {
-	Message     string      `json:"msg"`
-	Code        int         `json:"code"`
-	Data        interface{} `json:"data"`
-	Total       int32       `json:"total"`
-	TotalPage   int32       `json:"totalPage"`
-	PageSize    string      `json:"pageSize"`
-	CurrentPage string      `json:"currentPage"`
+	Message     string      `json:"msg" xml:"msg" msgpack:"msg"`
+	Code        int         `json:"code" xml:"code" msgpack:"code"`
+	Data        interface{} `json:"data" xml:"data" msgpack:"data"`
+	Total       int32       `json:"total" xml:"total" msgpack:"total"`
+	TotalPage   int32       `json:"totalPage" xml:"totalPage" msgpack:"totalPage"`
+	PageSize    string      `json:"pageSize" xml:"pageSize" msgpack:"pageSize"`
+	CurrentPage string      `json:"currentPage" xml:"currentPage" msgpack:"currentPage"`
 }
 
 // ---- Builder ----
 type RespBuilder struct {
-	writer http.ResponseWriter
+	ctx    *Context //由 Context.Resp 创建时使用 Router 的 JSON 编解码和错误处理
 	isPage bool
 	result *Result
 	res    *Resource
@@ -52,9 +51,10 @@ type ResultBuilder struct {
 	writer http.ResponseWriter
 }
 
+// NewResp 不经过 Router 创建时使用默认的 StdJSON，序列化失败返回 500
 func NewResp(w http.ResponseWriter) *RespBuilder {
 	return &RespBuilder{
-		writer: w,
+		ctx:    &Context{Writer: w},
 		result: &Result{Code: StatusOk, Message: "OK"},
 	}
 }
@@ -125,14 +125,31 @@ func (b *RespBuilder) CurrentPage(page string) *RespBuilder {
 
 // ---- 输出 ----
 func (b *RespBuilder) JSON() {
-	w := b.writer
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	b.ctx.writeJSON(http.StatusOK, b.value())
+}
+
+func (b *RespBuilder) XML() {
+	b.ctx.XML(http.StatusOK, b.value())
+}
+
+func (b *RespBuilder) MsgPack() {
+	b.ctx.MsgPack(http.StatusOK, b.value())
+}
 
+// 按 Accept 请求头选择输出格式，需通过 Context.Resp 创建，否则输出 JSON
+func (b *RespBuilder) Negotiate() {
+	if b.ctx.Request == nil {
+		b.JSON()
+		return
+	}
+	b.ctx.Negotiate(http.StatusOK, b.value())
+}
+
+func (b *RespBuilder) value() interface{} {
 	if b.isPage {
-		_ = json.NewEncoder(w).Encode(b.res)
-	} else {
-		_ = json.NewEncoder(w).Encode(b.result)
+		return b.res
 	}
+	return b.result
 }
 
 func (b *RespBuilder) Error(code int) *RespBuilder {
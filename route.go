@@ -1,12 +1,10 @@
 package gts
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
-	"reflect"
-	"runtime"
-	"strings"
 	"syscall"
 	"time"
 	"context"
@@ -17,36 +15,57 @@ type IRouter interface {
 }
 
 type Router struct {
-	rLen    []int
-	routes  []map[string]HandlerFunc
-	mws     []HandlerFun
+	*table
+	*settings
+	mws  []HandlerFun
+	base string
+	host string //Host 子路由的域名规则
+
+	hosts      *[]*hostTable     //按域名区分的路由表
+	routeInfos *[]*RouteInfo     //路由表，分组子路由共用
+	names      map[string]string //命名路由 => 路径
+
+	constraints map[string]func(string) bool //路径参数约束，如 {id:int}
+}
+
+// 路由配置，分组及 Host 子路由共用，在任一子路由上设置均对整个 Router 生效
+type settings struct {
 	session Session
-	base    string
+	logger  RouteLogger
+
+	notFound   HandlerFunc //路径不存在
+	notAllowed HandlerFunc //路径存在但请求方法未注册
+
+	jsonCodec JSONCodec        //JSON 编解码
+	onError   ErrorHandlerFunc //统一的错误处理
+
+	trailingSlash   bool //末尾斜杠不一致时重定向
+	cleanPath       bool //路径不规范时重定向
+	caseInsensitive bool //大小写不一致时重定向
+
+	readTimeout   int
+	writeTimeout  int
+	cookieExpires int //cookie的默认过期时间，分钟，此配置不影响session过期设置
 }
 
 type HandlerFunc func(*http.Request, *Context)
 type HandlerFun func(HandlerFunc) HandlerFunc
 
+//请求方法编号，路由已按方法名存储，保留以兼容旧代码
 var (
-	fileLen    = 0
-	fileRoutes map[string]http.HandlerFunc
-	mwRoutes   map[string]HandlerFun
-	handler    map[string]HandlerFunc
-	Type       = map[string]int{
-		"Any":    0,
-		"GET":    1,
-		"POST":   2,
-		"DELETE": 3,
-		"PUT":    4,
+	Type = map[string]int{
+		"Any":     0,
+		"GET":     1,
+		"POST":    2,
+		"DELETE":  3,
+		"PUT":     4,
+		"PATCH":   5,
+		"HEAD":    6,
+		"OPTIONS": 7,
 	}
 )
-//设置cookie的默认过期时间，分钟，此配置不影响session过期设置
-var cookieExpiresTime = 15
-var srvReadTimeout int = 30
-var srvWriteTimeout int = 60
-
-var session Session
-var logger RouteLogger
+//cookie的默认过期时间，分钟
+const defaultCookieExpires = 15
 
 type RouteLogger interface {
 	Println(v ...interface{})
@@ -54,63 +73,72 @@ type RouteLogger interface {
 
 func New() *Router {
 
-	fileRoutes = make(map[string]http.HandlerFunc)
-	mwRoutes = make(map[string]HandlerFun)
-	handler = make(map[string]HandlerFunc)
-
 	return &Router{
-		routes:  []map[string]HandlerFunc{make(map[string]HandlerFunc), make(map[string]HandlerFunc), make(map[string]HandlerFunc), make(map[string]HandlerFunc), make(map[string]HandlerFunc)},
-		rLen:    make([]int, 5),
-		session: nil,
-		base:    "",
+		table:       newTable(),
+		base:        "",
+		hosts:       &[]*hostTable{},
+		routeInfos:  &[]*RouteInfo{},
+		names:       make(map[string]string),
+		constraints: defaultConstraints(),
+		settings: &settings{
+			session:       nil,
+			jsonCodec:     StdJSON{},
+			readTimeout:   30,
+			writeTimeout:  60,
+			cookieExpires: defaultCookieExpires,
+			trailingSlash: true,
+			cleanPath:     true,
+		},
 	}
 }
 func (p *Router) SessionExpires(minute int){
 
-	cookieExpiresTime = minute
+	p.cookieExpires = minute
 }
 func (p *Router) Cookie(cookieName string, maxLifeTime, cookieTime int64, secure bool) {
 
-	session = NewCookieSession(cookieName, maxLifeTime, cookieTime, secure)
-	p.session = session
+	p.session = NewCookieSession(cookieName, maxLifeTime, cookieTime, secure)
 
 }
 func (p *Router) Redis(cookieName string, maxLifeTime, cookieTime int64, secure bool, RedisHost, RedisPwd string, database ...int) {
 
-	session = NewRedisSession(cookieName, maxLifeTime, cookieTime, secure, RedisHost, RedisPwd, database...)
-	p.session = session
+	p.session = NewRedisSession(cookieName, maxLifeTime, cookieTime, secure, RedisHost, RedisPwd, database...)
 
 }
 
 func (p *Router) Logger(log RouteLogger) {
-	logger = log
+	p.logger = log
 }
 
-func print(v ...interface{}) {
-	if logger != nil {
-		logger.Println(v)
+func (p *Router) print(v ...interface{}) {
+	if p != nil && p.settings != nil && p.logger != nil {
+		p.logger.Println(v)
 	}
 }
 
 func (p *Router) ServerTimeout(readTimeout, writeTimeout int) {
 
-	srvReadTimeout = readTimeout
-	srvWriteTimeout = writeTimeout
+	p.readTimeout = readTimeout
+	p.writeTimeout = writeTimeout
 }
 
 func (p *Router) Run(addr string) {
 
+	if p.dumpRoutes() {
+		os.Exit(0)
+	}
+
 	srv := &http.Server{
 		Addr:           addr,
 		Handler:        p,
-		ReadTimeout:    time.Duration(srvReadTimeout) * time.Second,
-		WriteTimeout:   time.Duration(srvWriteTimeout) * time.Second,
+		ReadTimeout:    time.Duration(p.readTimeout) * time.Second,
+		WriteTimeout:   time.Duration(p.writeTimeout) * time.Second,
 		MaxHeaderBytes: 1 << 20, // 1 MB
 	}
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil {
-			print(err)
+			p.print(err)
 			os.Exit(1)
 		}
 	}()
@@ -118,15 +146,15 @@ func (p *Router) Run(addr string) {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	print("Shutdown Server ...")
+	p.print("Shutdown Server ...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		print("Server Shutdown:", err)
+		p.print("Server Shutdown:", err)
 	}
 
-	print("Server exiting")
+	p.print("Server exiting")
 
 }
 
@@ -135,78 +163,118 @@ func (p *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	url := r.URL.Path
 	method := r.Method
 
-	print("[", method, "]", url)
+	p.print("[", method, "]", url)
 
-	if fileLen > 0 && isStatic(url) { //静态资源
-		for k, f := range fileRoutes {
+	w = &responseWriter{ResponseWriter: w}
+	ctx := &Context{Writer: w, Request: r, Sessions: p.session, router: p}
+	ctx.params = ctx.paramBuf[:0]
+	defer ctx.runAfter()
 
-			if strings.HasPrefix(url, k) {
-				f(w, r)
-				return
-			}
-		}
-		print("not found file:", r.URL.String())
-		http.Error(w, "Bad file:"+r.URL.String(), http.StatusBadRequest)
-		return
+	t := p.table
+	if h := p.matchHost(r.Host, ctx); h != nil {
+		t = h
 	}
 
-	ctx := &Context{Writer: w, Request: r, Sessions: p.session}
+	if p.cleanPath && method != http.MethodConnect {
+		if cp := cleanEscapedPath(r.URL); cp != "" {
+			redirect(w, r, cp)
+			return
+		}
+	}
 
-	var t int = 0
-	t = Type[method]
-	if t > 0 && p.rLen[t] > 0 {
+	if root := t.trees[method]; root != nil {
 
-		if fun, ok := p.routes[t][url]; ok {
+		if fun := root.find(url, &ctx.params); fun != nil {
 			fun(r, ctx)
 			return
 		}
+		ctx.params = ctx.params[:0]
 	}
 
-	if len(handler) > 0 {
-		for k, f := range handler {
+	if method == http.MethodHead { //未注册HEAD时使用GET路由，响应体由http.Server丢弃
+		if root := t.trees[http.MethodGet]; root != nil {
 
-			if strings.HasPrefix(url+"/", k+"/") {
-				ctx := &Context{Writer: w, Request: r, Sessions: p.session}
-            	f(r, ctx)  // 之前: f(w, r)
+			if fun := root.find(url, &ctx.params); fun != nil {
+				fun(r, ctx)
 				return
 			}
+			ctx.params = ctx.params[:0]
 		}
 	}
 
-	if fileLen > 0 && isStatic(url){ //静态目录
-		for k, f := range fileRoutes {
+	if method == http.MethodOptions { //未注册OPTIONS时自动应答允许的方法
+		if allow := t.allowed(url); allow != "" {
+			middleware(p.mws, func(r *http.Request, c *Context) {
 
-			if strings.HasPrefix(url, k) {
-				f(w, r)
-				return
-			}
+				c.Writer.Header().Set("Allow", allow)
+				c.Writer.WriteHeader(http.StatusOK)
+			})(r, ctx)
+			return
 		}
-		print("not found file:", r.URL.String())
-		http.Error(w, "Bad file:"+r.URL.String(), http.StatusBadRequest)
+	}
+
+	if p.serveMount(t, r, ctx, url) {
 		return
 	}
 
-	nofound := fileRoutes["No-Found-URL-Error-404"]
-	if nofound != nil {
+	if method != http.MethodConnect {
+		if to := p.canonical(t, method, url); to != "" {
+			redirect(w, r, escapePath(to))
+			return
+		}
+	}
 
-		nofound(w, r)
+	if allow := t.allowed(url); allow != "" {
+
+		p.print("method not allowed:", method, r.URL.String())
+		w.Header().Set("Allow", allow)
+		if p.notAllowed != nil {
+			middleware(p.mws, p.notAllowed)(r, ctx)
+			return
+		}
+		http.Error(w, "Method Not Allowed:"+method, http.StatusMethodNotAllowed)
 		return
 	}
 
-	print("not found URL:", r.URL.String())
-	http.Error(w, "Bad URL:"+r.URL.String(), http.StatusBadRequest)
+	p.print("not found URL:", r.URL.String())
+	if p.notFound != nil {
+		middleware(p.mws, p.notFound)(r, ctx)
+		return
+	}
+	http.Error(w, "Not Found:"+r.URL.String(), http.StatusNotFound)
 
 }
 func (p *Router) GetSession(r *http.Request, key string) interface{} {
 
-	v, _ := session.Get(r, key)
+	v, _ := p.session.Get(r, key)
 	return v
 }
 
-func isStatic(url string) bool {
+// 静态资源与 Handler/Mount 挂载按最长前缀匹配，静态文件不存在时继续尝试挂载
+func (p *Router) serveMount(t *table, r *http.Request, ctx *Context, url string) bool {
 
-	return strings.Contains(url, ".")
+	var fn *node
+	var frest string
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		fn, frest = t.files.longest(url)
+	}
+	hn, hrest := t.handlers.longest(url)
 
+	if fn != nil && (hn == nil || len(frest) <= len(hrest)) {
+		name := frest
+		if name == "" || name[0] != '/' {
+			name = "/" + name
+		}
+		if fn.file.serve(ctx.Writer, r, name) {
+			return true
+		}
+		p.print("not found file:", url)
+	}
+	if hn != nil {
+		hn.h(r, ctx)
+		return true
+	}
+	return false
 }
 
 //执行中间件
@@ -215,52 +283,44 @@ func middleware(mws []HandlerFun, h HandlerFunc) HandlerFunc {
 	l := len(mws)
 	for i := l - 1; i >= 0; i-- {
 
-		h = mws[i](h)
+		h = mws[i](abortable(h))
 	}
 	return h
 }
 
-//执行拦截器
-func filter(url string, h HandlerFunc) HandlerFunc {
-    v := reflect.ValueOf(h)
-    fn := runtime.FuncForPC(v.Pointer()).Name()
-    
-    // 创建一个临时的 HandlerFun slice 来存储所有匹配的过滤器
-    var matchedFilters []HandlerFun
-    
-    for k, f := range mwRoutes {
-        if strings.Contains(fn, k) { // 按类名匹配
-            matchedFilters = append(matchedFilters, f)
-        }
-        if strings.HasPrefix(url, k) { // 按url匹配
-            matchedFilters = append(matchedFilters, f)
-        }
-    }
-    
-    // 如果有匹配的过滤器，使用 middleware 函数应用它们
-    if len(matchedFilters) > 0 {
-        h = middleware(matchedFilters, h)
-    }
-    
-    return h
+// 调用 Context.Abort 后不再执行后续的中间件和处理函数
+func abortable(h HandlerFunc) HandlerFunc {
+
+	return func(r *http.Request, c *Context) {
+
+		if c.aborted {
+			return
+		}
+		h(r, c)
+	}
 }
-func (p *Router) add(i int, path string, h HandlerFunc, f ...HandlerFun) {
+
+func (p *Router) add(method, path string, h HandlerFunc, f ...HandlerFun) *Route {
 
 	url := p.base + path
-	m := p.routes[i]
 
-	vh := reflect.ValueOf(h)
-	fn := runtime.FuncForPC(vh.Pointer()).Name()
+	fn := funcName(h)
 
-	print(url, " ==> ", fn)
+	p.print("[", method, "]", url, " ==> ", fn)
+	info := p.addRouteInfo(method, url, fn, append(p.mws[:len(p.mws):len(p.mws)], f...))
 
 	if len(f) > 0 {
 
 		h = middleware(f, h)
 	}
 	
-	m[url] = filter(url, middleware(p.mws, h))
-	p.rLen[i]++
+	root := p.trees[method]
+	if root == nil {
+		root = &node{}
+		p.trees[method] = root
+	}
+	root.add(url, middleware(p.mws, h), p.constraints)
+	return &Route{router: p, infos: []*RouteInfo{info}}
 }
 // route.handler("/user", handlerFunc)  访问： /user/abc 匹配
 func (p *Router) Handler(relativePath string, h http.HandlerFunc, f ...HandlerFun) {
@@ -276,46 +336,12 @@ func (p *Router) Handler(relativePath string, h http.HandlerFunc, f ...HandlerFu
         adapted = middleware(f, adapted)
     }
 
-    // 应用全局中间件 + mwRoutes 拦截器（与 add() 保持一致）
-    handler[url] = filter(url, middleware(p.mws, adapted))
-    print(url, " ==> ", &h)
-}
-//常规静态资源，如 css/js/images
-func (p *Router) Static(relativePath string, dirPath string) {
-
-	fileRoutes[relativePath] = func(w http.ResponseWriter, r *http.Request) {
-
-		http.StripPrefix(relativePath, http.FileServer(http.Dir(dirPath))).ServeHTTP(w, r)
-	}
-	fileLen++
-}
-
-// 指定目录结构，只能访问文件，无法递归目录
-func (p *Router) StaticDir(relativePath string, dir string) {
-
-	fileRoutes[relativePath] = func(w http.ResponseWriter, r *http.Request) {
-
-		file := dir + r.URL.Path[1:len(r.URL.Path)]
-
-		info, err := os.Stat(file)
-		if err == nil && !info.IsDir() {
-			http.ServeFile(w, r, file)
-		} else {
-
-			w.WriteHeader(404)
-			w.Write([]byte(`not found ` + file))
-		}
-
-	}
-	fileLen++
-}
-// 自行处理文件实现，如OSS/FileDB/S3/虚拟文件系统
-func (p *Router) StaticFs(relativePath string, handler http.HandlerFunc) {
-
-	fileRoutes[relativePath] = handler
-	fileLen++
+    // 应用全局及分组中间件（与 add() 保持一致）
+    p.handlers.addStatic(url).h = middleware(p.mws, adapted)
+    p.print(url, " ==> ", funcName(h))
+    p.addRouteInfo(AnyMethod, url, funcName(h), append(p.mws[:len(p.mws):len(p.mws)], f...))
 }
-func (p *Router) File(relativePath string, filePath string, filter ...HandlerFun) {
+func (p *Router) File(relativePath string, filePath string, filter ...HandlerFun) *Route {
 
 	var handler = func(req *http.Request, c *Context) {
 
@@ -325,27 +351,44 @@ func (p *Router) File(relativePath string, filePath string, filter ...HandlerFun
 			http.ServeFile(c.Writer, req, filePath)
 		}
 	}
-	p.add(1, relativePath, handler, filter...)
+	return p.add(http.MethodGet, relativePath, handler, filter...)
 
 }
 
-func (p *Router) NoFound(handler http.HandlerFunc) {
+// 路径不存在时的处理，默认返回 404
+// handler 可以是 gts.HandlerFunc 或 http.HandlerFunc，经过全局中间件
+func (p *Router) NoFound(handler interface{}) {
 
-	fileRoutes["No-Found-URL-Error-404"] = handler
-	fileLen++
+	p.notFound = toHandlerFunc(handler)
 }
 
+// 路径存在但请求方法未注册时的处理，默认返回 405，调用前已设置 Allow 头
+// handler 可以是 gts.HandlerFunc 或 http.HandlerFunc，经过全局中间件
+func (p *Router) MethodNotAllowed(handler interface{}) {
 
-func (p *Router) Favicon(dirPath string) {
+	p.notAllowed = toHandlerFunc(handler)
+}
 
-	fileRoutes["/favicon.ico"] = func(w http.ResponseWriter, r *http.Request) {
+// 转换为 HandlerFunc，支持 gts.HandlerFunc、http.HandlerFunc、http.Handler 及对应的函数字面量
+func toHandlerFunc(h interface{}) HandlerFunc {
 
-		file := dirPath + "favicon.ico"
-		if _, err := os.Stat(file); err == nil {
-			http.ServeFile(w, r, file)
+	switch f := h.(type) {
+	case nil:
+		return nil
+	case HandlerFunc:
+		return f
+	case func(*http.Request, *Context):
+		return f
+	case func(http.ResponseWriter, *http.Request):
+		return func(r *http.Request, c *Context) {
+			f(c.Writer, r)
+		}
+	case http.Handler:
+		return func(r *http.Request, c *Context) {
+			f.ServeHTTP(c.Writer, r)
 		}
 	}
-	fileLen++
+	panic(fmt.Sprintf("gts: unsupported handler type %T", h))
 }
 
 //添加中间件
@@ -354,67 +397,89 @@ func (p *Router) Use(h HandlerFun) {
 	p.mws = append(p.mws, h)
 }
 
-func (p *Router) Any(relativePath string, handler HandlerFunc, filter ...HandlerFun) {
+func (p *Router) Any(relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
 
-	p.add(1, relativePath, handler, filter...)
-	p.add(2, relativePath, handler, filter...)
+	r := p.add(http.MethodGet, relativePath, handler, filter...)
+	r.infos = append(r.infos, p.add(http.MethodPost, relativePath, handler, filter...).infos...)
+	return r
 
 }
 
-func (p *Router) Get(relativePath string, handler HandlerFunc, filter ...HandlerFun) {
+func (p *Router) Get(relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
+
+	return p.add(http.MethodGet, relativePath, handler, filter...)
 
-	p.add(1, relativePath, handler, filter...)
+}
+func (p *Router) Post(relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
+
+	return p.add(http.MethodPost, relativePath, handler, filter...)
 
 }
-func (p *Router) Post(relativePath string, handler HandlerFunc, filter ...HandlerFun) {
+func (p *Router) Delete(relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
 
-	p.add(2, relativePath, handler, filter...)
+	return p.add(http.MethodDelete, relativePath, handler, filter...)
 
 }
-func (p *Router) Delete(relativePath string, handler HandlerFunc, filter ...HandlerFun) {
+func (p *Router) Put(relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
 
-	p.add(3, relativePath, handler, filter...)
+	return p.add(http.MethodPut, relativePath, handler, filter...)
 
 }
-func (p *Router) Put(relativePath string, handler HandlerFunc, filter ...HandlerFun) {
+func (p *Router) Patch(relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
 
-	p.add(4, relativePath, handler, filter...)
+	return p.add(http.MethodPatch, relativePath, handler, filter...)
 
 }
 
-func (p *Router) Group(url string, h func(r *Router), params ...HandlerFun) {
+// 未注册HEAD时，HEAD请求自动使用GET路由
+func (p *Router) Head(relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
 
-	p.base = url
-	if len(params) > 0 {
-		//mwRoutes[url] = params[0]
-		combinedFilter := func(next HandlerFunc) HandlerFunc {
+	return p.add(http.MethodHead, relativePath, handler, filter...)
 
-            		return middleware(params, next)
-        	}
-        
-        	mwRoutes[url] = combinedFilter
-	}
-	h(p)
-	p.base = ""
 }
 
-func (p *Router) Route(url string, i IRouter, params ...HandlerFun) {
+// 未注册OPTIONS时，OPTIONS请求自动应答Allow头
+func (p *Router) Options(relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
 
-	t := reflect.TypeOf(i).String()
+	return p.add(http.MethodOptions, relativePath, handler, filter...)
 
-	if len(params) > 0 {
+}
 
-		idx := strings.LastIndex(t, ".")
-		if idx > 0 {
+// 任意请求方法，包括自定义方法，如 route.Handle("PROPFIND", "/dav", handler)
+func (p *Router) Handle(method, relativePath string, handler HandlerFunc, filter ...HandlerFun) *Route {
 
-			idx++
-			t = string([]rune(t)[idx:len(t)])
-		}
-		mwRoutes[t] = params[0]
+	if method == "" {
+		panic("gts: empty method for path " + relativePath)
 	}
-	p.base = url
-	i.Router(p)
-	p.base = ""
+	return p.add(method, relativePath, handler, filter...)
+
+}
+
+// 路由分组，返回子路由并传给 h（h 可为 nil）
+// 子路由继承父路由的前缀和中间件，可以再 Use 自己的中间件，也可以继续 Group 嵌套
+// NoFound、Logger、UseJSON 等配置由所有子路由共用，在子路由上设置同样作用于整个 Router
+func (p *Router) Group(url string, h func(r *Router), params ...HandlerFun) *Router {
+
+	g := p.child(url, params)
+	if h != nil {
+		h(g)
+	}
+	return g
+}
+
+// 子路由与父路由共用路由表，只有前缀和中间件各自独立
+func (p *Router) child(prefix string, mws []HandlerFun) *Router {
+
+	c := *p
+	c.base = p.base + prefix
+	c.mws = append(p.mws[:len(p.mws):len(p.mws)], mws...)
+	return &c
+}
+
+// 注册控制器，params 作为拦截器按顺序绑定到该控制器注册的全部路由
+func (p *Router) Route(url string, i IRouter, params ...HandlerFun) {
+
+	i.Router(p.child(url, params))
 
 }
 func ErrRespone(next HandlerFunc) HandlerFunc {
@@ -423,7 +488,7 @@ func ErrRespone(next HandlerFunc) HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 
-				print(err)
+				ctx.router.print(err)
 				ctx.WriteString(err.(string))
 				return
 			}
@@ -446,7 +511,7 @@ func Cors(next HandlerFunc) HandlerFunc {
 		w := ctx.Writer
 		w.Header().Set("Access-Control-Allow-Origin", origin)
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		// 处理预检请求（OPTIONS 请求）
@@ -0,0 +1,372 @@
+package gts
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func serve(r *Router, method, url string, header map[string]string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest(method, url, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// 两个 Router 互不影响，可以并行测试
+func TestRoutersParallel(t *testing.T) {
+
+	for _, tt := range []struct {
+		name    string
+		expires int
+		path    string
+	}{
+		{"admin", 30, "/admin"},
+		{"public", 5, "/public"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			var buf bytes.Buffer
+			r := New()
+			r.SessionExpires(tt.expires)
+			r.Logger(log.New(&buf, "", 0))
+			r.Get(tt.path, func(req *http.Request, c *Context) {
+				c.SetCookieAndDomain("sid", "1")
+				c.WriteString(tt.name)
+			})
+
+			for i := 0; i < 50; i++ {
+				w := serve(r, http.MethodGet, tt.path, nil)
+				if w.Code != 200 || w.Body.String() != tt.name {
+					t.Fatalf("got %d %q", w.Code, w.Body.String())
+				}
+				cookies := w.Result().Cookies()
+				if len(cookies) != 1 || cookies[0].MaxAge != 60*tt.expires {
+					t.Fatalf("cookie %v, want MaxAge %d", cookies, 60*tt.expires)
+				}
+			}
+
+			other := "/admin"
+			if tt.path == other {
+				other = "/public"
+			}
+			if !strings.Contains(buf.String(), tt.path) || strings.Contains(buf.String(), other) {
+				t.Fatalf("log mixed between routers:\n%s", buf.String())
+			}
+			if w := serve(r, http.MethodGet, other, nil); w.Code == http.StatusOK {
+				t.Fatalf("%s: served by the other router", other)
+			}
+		})
+	}
+}
+
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+
+	r := New()
+	h := func(req *http.Request, c *Context) { c.WriteString(req.Method) }
+	r.Get("/user/:id", h)
+	r.Put("/user/:id", h)
+
+	tests := []struct {
+		method, url string
+		status      int
+		allow       string
+	}{
+		{http.MethodGet, "/user/1", 200, ""},
+		{http.MethodHead, "/user/1", 200, ""},
+		{http.MethodPost, "/user/1", 405, "GET, HEAD, OPTIONS, PUT"},
+		{http.MethodDelete, "/user/1", 405, "GET, HEAD, OPTIONS, PUT"},
+		{http.MethodOptions, "/user/1", 200, "GET, HEAD, OPTIONS, PUT"},
+		{http.MethodGet, "/nope", 404, ""},
+		{http.MethodPost, "/nope", 404, ""},
+	}
+	for _, tt := range tests {
+		w := serve(r, tt.method, tt.url, nil)
+		if w.Code != tt.status || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: got %d Allow %q, want %d Allow %q", tt.method, tt.url, w.Code, w.Header().Get("Allow"), tt.status, tt.allow)
+		}
+	}
+
+	r.NoFound(func(req *http.Request, c *Context) { c.JSON(404, M{"msg": "not found"}) })
+	r.MethodNotAllowed(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "custom 405", http.StatusMethodNotAllowed)
+	})
+	if w := serve(r, http.MethodGet, "/nope", nil); w.Code != 404 || !strings.Contains(w.Body.String(), `"msg":"not found"`) {
+		t.Errorf("NoFound: got %d %q", w.Code, w.Body.String())
+	}
+	if w := serve(r, http.MethodPost, "/user/1", nil); w.Code != 405 || w.Header().Get("Allow") == "" || !strings.Contains(w.Body.String(), "custom 405") {
+		t.Errorf("MethodNotAllowed: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestParamConstraints(t *testing.T) {
+
+	r := New()
+	r.Constraint("even", func(s string) bool {
+		n, err := strconv.Atoi(s)
+		return err == nil && n%2 == 0
+	})
+	route := func(name string) HandlerFunc {
+		return func(req *http.Request, c *Context) { c.WriteString(name + ":" + c.Param("v")) }
+	}
+	r.Get("/order/{v:int}", route("int"))
+	r.Get("/order/{v:uuid}", route("uuid"))
+	r.Get("/order/:v", route("any"))
+	r.Get("/doc/{v:[a-z-]+}", route("slug"))
+	r.Get("/num/{v:even}", route("even"))
+
+	tests := []struct {
+		url, body string
+		status    int
+	}{
+		{"/order/42", "int:42", 200},
+		{"/order/-7", "int:-7", 200},
+		{"/order/3f2504e0-4f89-11d3-9a0c-0305e82c3301", "uuid:3f2504e0-4f89-11d3-9a0c-0305e82c3301", 200},
+		{"/order/abc", "any:abc", 200},
+		{"/doc/hello-world", "slug:hello-world", 200},
+		{"/doc/Hello", "", 404},
+		{"/num/4", "even:4", 200},
+		{"/num/3", "", 404},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, tt.url, nil)
+		if w.Code != tt.status || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s: got %d %q, want %d %q", tt.url, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("bad constraint expression: want panic")
+		}
+	}()
+	r.Get("/bad/{v:[a-}", route("bad"))
+}
+
+func TestRedirects(t *testing.T) {
+
+	r := New()
+	h := func(req *http.Request, c *Context) { c.WriteString("ok") }
+	r.Get("/users", h)
+	r.Get("/docs/", h)
+	r.Post("/orders", h)
+	r.Get("/Users/:id/Profile", h)
+	r.Get("/a b/", h)
+	r.Static("/static", writeFiles(t, map[string]string{"x?y/index.html": "<x>"}))
+
+	tests := []struct {
+		method, url string
+		status      int
+		location    string
+	}{
+		{http.MethodGet, "/users/", 301, "/users"},
+		{http.MethodGet, "/docs", 301, "/docs/"},
+		{http.MethodGet, "/users/?page=2", 301, "/users?page=2"},
+		{http.MethodPost, "/orders/", 308, "/orders"},
+		{http.MethodGet, "//users", 301, "/users"},
+		{http.MethodGet, "/a/../users", 301, "/users"},
+		{http.MethodGet, "/docs/./", 301, "/docs/"},
+		{http.MethodGet, "/USERS", 404, ""},                      //默认区分大小写
+		{http.MethodGet, "/%5Cevil.com//", 301, "/%5Cevil.com/"}, //"/\" 会被浏览器当作 "//"
+		{http.MethodGet, "/x%3Fy//", 301, "/x%3Fy/"},             //"?" 不能变成查询字符串
+		{http.MethodGet, "/x%3Fy//?a=1", 301, "/x%3Fy/?a=1"},
+		{http.MethodGet, "/a%2F%2Fb//", 301, "/a%2F%2Fb/"}, //编码的 "/" 不是路径分隔符
+		{http.MethodGet, "/a/%2E%2E/users", 301, "/users"},
+		{http.MethodGet, "/a%20b", 301, "/a%20b/"},
+	}
+	for _, tt := range tests {
+		w := serve(r, tt.method, tt.url, nil)
+		if w.Code != tt.status || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: got %d %q, want %d %q", tt.method, tt.url, w.Code, w.Header().Get("Location"), tt.status, tt.location)
+		}
+	}
+
+	if w := serve(r, http.MethodGet, "/static/x%3Fy", nil); w.Code != 301 || w.Header().Get("Location") != "/static/x%3Fy/" {
+		t.Errorf("static dir: got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	r.RedirectCaseInsensitive(true)
+	if w := serve(r, http.MethodGet, "/USERS/", nil); w.Code != 301 || w.Header().Get("Location") != "/users" {
+		t.Errorf("case insensitive: got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if w := serve(r, http.MethodGet, "/users/John/profile", nil); w.Code != 301 || w.Header().Get("Location") != "/Users/John/Profile" {
+		t.Errorf("case insensitive param: got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	r.RedirectTrailingSlash(false)
+	r.RedirectCleanPath(false)
+	if w := serve(r, http.MethodGet, "/docs", nil); w.Code != 404 {
+		t.Errorf("trailing slash off: got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "//users", nil); w.Code != 404 {
+		t.Errorf("clean path off: got %d", w.Code)
+	}
+}
+
+func TestMount(t *testing.T) {
+
+	var got string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = req.URL.Path + " " + req.URL.EscapedPath() + " " + OriginalPath(req)
+	})
+	trace := func(name string) HandlerFun {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(req *http.Request, c *Context) {
+				c.Writer.Header().Add("X-Trace", name)
+				if req.Header.Get("Deny") == name {
+					c.AbortWithStatus(http.StatusForbidden)
+				}
+				next(req, c)
+			}
+		}
+	}
+
+	inner := New()
+	inner.Mount("/inner", echo)
+
+	r := New()
+	r.Use(trace("global"))
+	r.Mount("/legacy", echo, trace("mount"))
+	r.Group("/api", func(g *Router) {
+		g.Mount("/v1", echo)
+	}, trace("group"))
+	r.Mount("/outer", inner)
+	r.Mount("/assets/api", echo)
+	r.Static("/assets", writeFiles(t, map[string]string{"a.js": "js", "api.js": "api"}))
+	r.Mount("/files", echo)
+	r.Static("/files/public", writeFiles(t, map[string]string{"a.txt": "txt"}))
+
+	tests := []struct {
+		url, deny   string
+		status      int
+		trace, body string
+		got         string
+	}{
+		{"/legacy/users?a=1", "", 200, "global,mount", "", "/users /users /legacy/users"},
+		{"/legacy", "", 200, "global,mount", "", "/ / /legacy"},
+		{"/legacy/a%2Fb", "", 200, "global,mount", "", "/a/b /a%2Fb /legacy/a/b"},
+		{"/legacy/x", "mount", 403, "global,mount", "", ""},
+		{"/legacy/x", "global", 403, "global", "", ""},
+		{"/legacyx", "", 404, "", "", ""},
+		{"/api/v1/x", "", 200, "global,group", "", "/x /x /api/v1/x"},
+		{"/outer/inner/x", "", 200, "global", "", "/x /x /outer/inner/x"}, //嵌套挂载保留最外层路径
+		{"/assets/api/x", "", 200, "global", "", "/x /x /assets/api/x"},   //最长前缀优先
+		{"/assets/api.js", "", 200, "", "api", ""},
+		{"/assets/a.js", "", 200, "", "js", ""},
+		{"/files/public/a.txt", "", 200, "", "txt", ""},
+		{"/files/other", "", 200, "global", "", "/other /other /files/other"},
+	}
+	for _, tt := range tests {
+		got = ""
+		w := serve(r, http.MethodGet, tt.url, map[string]string{"Deny": tt.deny})
+		if w.Code != tt.status || got != tt.got {
+			t.Errorf("%s: got %d %q, want %d %q", tt.url, w.Code, got, tt.status, tt.got)
+		}
+		if trace := strings.Join(w.Header()["X-Trace"], ","); trace != tt.trace || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s: trace %q body %q, want %q %q", tt.url, trace, w.Body.String(), tt.trace, tt.body)
+		}
+	}
+}
+
+// 中间件把 name 追加到 X-Trace 响应头
+func traceMW(name string) HandlerFun {
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(req *http.Request, c *Context) {
+			c.Writer.Header().Add("X-Trace", name)
+			next(req, c)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+
+	h := func(req *http.Request, c *Context) { c.WriteString(req.URL.Path) }
+
+	r := New()
+	r.Use(traceMW("root"))
+	api := r.Group("/api", func(g *Router) {
+		g.Get("/ping", h)
+		g.Group("/v1", func(v1 *Router) {
+			v1.Use(traceMW("v1.use"))
+			v1.Get("/users", h)
+		}, traceMW("v1"))
+	}, traceMW("api"))
+	api.Use(traceMW("api.use")) //之后注册的路由才生效
+	api.Get("/late", h)
+	r.Get("/home", h)
+	api.NoFound(func(req *http.Request, c *Context) { c.Write(http.StatusNotFound, []byte("custom")) })
+
+	tests := []struct {
+		url, trace string
+		status     int
+	}{
+		{"/api/ping", "root,api", 200},
+		{"/api/v1/users", "root,api,v1,v1.use", 200},
+		{"/api/late", "root,api,api.use", 200},
+		{"/home", "root", 200}, //子路由的 Use 不影响父路由
+		{"/api/v1", "root", 404},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, tt.url, nil)
+		if trace := strings.Join(w.Header()["X-Trace"], ","); w.Code != tt.status || trace != tt.trace {
+			t.Errorf("%s: got %d %q, want %d %q", tt.url, w.Code, trace, tt.status, tt.trace)
+		}
+	}
+	//配置在子路由上设置同样作用于整个 Router
+	if w := serve(r, http.MethodGet, "/nope", nil); w.Body.String() != "custom" {
+		t.Errorf("NoFound on group: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+type userCtl struct{}
+
+func (userCtl) Router(r *Router) {
+
+	r.Get("/info", func(req *http.Request, c *Context) { c.WriteString("user") })
+}
+
+type userAdminCtl struct{}
+
+func (userAdminCtl) Router(r *Router) {
+
+	r.Get("/info", func(req *http.Request, c *Context) { c.WriteString("admin") })
+	r.Post("/info", func(req *http.Request, c *Context) { c.WriteString("admin") })
+}
+
+// Route 的拦截器只作用于该控制器注册的路由，类型名互为前缀时也不串用
+func TestRouteInterceptors(t *testing.T) {
+
+	r := New()
+	r.Route("/user", &userCtl{}, traceMW("user"))
+	r.Route("/admin/user", userAdminCtl{}, traceMW("admin"), traceMW("audit"))
+	r.Route("/public", userCtl{})
+	r.Get("/user/other", func(req *http.Request, c *Context) { c.WriteString("other") })
+
+	tests := []struct {
+		method, url, trace, body string
+	}{
+		{http.MethodGet, "/user/info", "user", "user"},
+		{http.MethodGet, "/admin/user/info", "admin,audit", "admin"},
+		{http.MethodPost, "/admin/user/info", "admin,audit", "admin"},
+		{http.MethodGet, "/public/info", "", "user"},
+		{http.MethodGet, "/user/other", "", "other"},
+	}
+	for _, tt := range tests {
+		w := serve(r, tt.method, tt.url, nil)
+		if trace := strings.Join(w.Header()["X-Trace"], ","); trace != tt.trace || w.Body.String() != tt.body {
+			t.Errorf("%s %s: got %q %q, want %q %q", tt.method, tt.url, trace, w.Body.String(), tt.trace, tt.body)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package gts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// 设置该环境变量后 Run 只打印路由表并退出，取值 text 或 json，供 cmd/gts-routes 使用
+const RoutesEnv = "GTS_ROUTES"
+
+// 不区分请求方法的路由，如 Handler、Static
+const AnyMethod = "*"
+
+// RouteInfo 已注册的路由信息
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Host       string   `json:"host,omitempty"`
+	Path       string   `json:"path"`
+	Name       string   `json:"name,omitempty"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware,omitempty"`
+}
+
+// Routes 返回全部已注册路由，按注册顺序
+func (p *Router) Routes() []RouteInfo {
+
+	list := make([]RouteInfo, len(*p.routeInfos))
+	for i, info := range *p.routeInfos {
+		list[i] = *info
+	}
+	return list
+}
+
+// PrintRoutes 以表格形式输出路由表
+func (p *Router) PrintRoutes(w io.Writer) {
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tHANDLER\tMIDDLEWARE")
+	for _, r := range *p.routeInfos {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Host+r.Path, r.Name, r.Handler, strings.Join(r.Middleware, ", "))
+	}
+	tw.Flush()
+}
+
+// DebugRoutes 注册调试接口，以 JSON 返回路由表，默认不开启，建议通过 filter 加访问控制
+func (p *Router) DebugRoutes(relativePath string, filter ...HandlerFun) {
+
+	p.add(http.MethodGet, relativePath, func(r *http.Request, c *Context) {
+
+		w := c.Writer
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(p.Routes())
+	}, filter...)
+}
+
+func (p *Router) addRouteInfo(method, path, handler string, mws []HandlerFun) *RouteInfo {
+
+	info := &RouteInfo{Method: method, Host: p.host, Path: path, Handler: handler}
+	for _, mw := range mws {
+		info.Middleware = append(info.Middleware, funcName(mw))
+	}
+	*p.routeInfos = append(*p.routeInfos, info)
+	return info
+}
+
+// 按环境变量 RoutesEnv 输出路由表，返回是否已输出
+func (p *Router) dumpRoutes() bool {
+
+	switch os.Getenv(RoutesEnv) {
+	case "":
+		return false
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(p.Routes())
+	default:
+		p.PrintRoutes(os.Stdout)
+	}
+	return true
+}
+
+func funcName(f interface{}) string {
+
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	return runtime.FuncForPC(v.Pointer()).Name()
+}
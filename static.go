@@ -0,0 +1,138 @@
+package gts
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// 静态资源挂载，serve 的 name 为去掉前缀后的路径（以 "/" 开头），文件不存在时返回 false，继续后续匹配
+type static struct {
+	serve func(w http.ResponseWriter, r *http.Request, name string) bool
+}
+
+//常规静态资源，如 css/js/images
+func (p *Router) Static(relativePath string, dirPath string) *StaticRoute {
+
+	return p.addStaticRoute(relativePath, "Static("+dirPath+")", &StaticRoute{files: rootDir(dirPath), dirs: true})
+}
+
+// 从 fs.FS 提供静态资源，支持 embed.FS、os.DirFS 及测试用的内存文件系统
+//
+//	//go:embed public
+//	var public embed.FS
+//
+//	sub, _ := fs.Sub(public, "public")
+//	route.StaticFS("/public", sub)
+func (p *Router) StaticFS(relativePath string, fsys fs.FS) *StaticRoute {
+
+	return p.addStaticRoute(relativePath, "StaticFS", &StaticRoute{files: http.FS(fsys), dirs: true})
+}
+
+func (p *Router) addStaticRoute(relativePath, name string, s *StaticRoute) *StaticRoute {
+
+	p.addFile(relativePath, name, func(w http.ResponseWriter, r *http.Request, name string) bool {
+
+		return s.serve(w, r, name) || s.fallback(w, r)
+	})
+	return s
+}
+
+// 指定目录结构，只能访问文件，不处理目录
+// 请求路径去掉前缀后对应 dir 下的文件，如 StaticDir("/files", "./data") 中 /files/a.txt 对应 ./data/a.txt
+func (p *Router) StaticDir(relativePath string, dir string) *StaticRoute {
+
+	return p.addStaticRoute(relativePath, "StaticDir("+dir+")", &StaticRoute{files: rootDir(dir)})
+}
+
+// 自行处理文件实现，如OSS/FileDB/S3/虚拟文件系统
+func (p *Router) StaticFs(relativePath string, handler http.HandlerFunc) {
+
+	p.addFile(relativePath, funcName(handler), func(w http.ResponseWriter, r *http.Request, name string) bool {
+
+		handler(w, r)
+		return true
+	})
+}
+
+func (p *Router) Favicon(dirPath string) {
+
+	p.addFile("/favicon.ico", "Favicon("+dirPath+")", func(w http.ResponseWriter, r *http.Request, name string) bool {
+
+		file := dirPath + "favicon.ico"
+		if name != "/" {
+			return false
+		}
+		if _, err := os.Stat(file); err != nil {
+			return false
+		}
+		http.ServeFile(w, r, file)
+		return true
+	})
+}
+
+// 从 fs.FS 根目录提供 favicon.ico
+func (p *Router) FaviconFS(fsys fs.FS) {
+
+	hfs := http.FS(fsys)
+	p.addFile("/favicon.ico", "FaviconFS", func(w http.ResponseWriter, r *http.Request, name string) bool {
+
+		if name != "/" {
+			return false
+		}
+		return serveFile(w, r, hfs, "/favicon.ico")
+	})
+}
+
+// 从 fs.FS 提供单个文件，name 为文件在 fsys 中的路径
+func (p *Router) FileFS(relativePath string, fsys fs.FS, name string, filter ...HandlerFun) *Route {
+
+	hfs := http.FS(fsys)
+	name = "/" + strings.TrimPrefix(name, "/")
+	var handler = func(req *http.Request, c *Context) {
+
+		if !serveFile(c.Writer, req, hfs, name) {
+			http.NotFound(c.Writer, req)
+		}
+	}
+	return p.add(http.MethodGet, relativePath, handler, filter...)
+}
+
+// 注册静态资源前缀，只响应 GET/HEAD，已注册的动态路由优先；
+// 与 Handler/Mount 挂载按最长前缀匹配，文件不存在时继续匹配，最终返回 404
+func (p *Router) addFile(prefix, name string, serve func(w http.ResponseWriter, r *http.Request, name string) bool) {
+
+	prefix = strings.TrimSuffix(p.base+prefix, "/")
+	p.files.addStatic(prefix).file = &static{serve: serve}
+	p.addRouteInfo(http.MethodGet, prefix+"/*", name, nil)
+}
+
+// 输出文件内容，文件不存在或为目录时返回 false
+func serveFile(w http.ResponseWriter, r *http.Request, files http.FileSystem, name string) bool {
+
+	f, err := files.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	return true
+}
+
+// 复制请求并替换路径，同 http.StripPrefix
+func stripPath(r *http.Request, name string) *http.Request {
+
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = name
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
@@ -0,0 +1,216 @@
+package gts
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// writeFiles 在临时目录中创建文件，返回目录路径
+func writeFiles(t *testing.T, files map[string]string) string {
+
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, body := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestStaticSPA(t *testing.T) {
+
+	dir := writeFiles(t, map[string]string{
+		"index.html":  "<app>",
+		"assets/a.js": "js",
+	})
+
+	r := New()
+	r.Get("/api/ping", func(req *http.Request, c *Context) { c.WriteString("pong") })
+	r.Static("/", dir).SPA("", "/api")
+
+	html := map[string]string{"Accept": "text/html,*/*;q=0.8"}
+	tests := []struct {
+		url    string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"/orders/42", html, 200, "<app>"},
+		{"/orders/42", nil, 200, "<app>"},
+		{"/assets/a.js", nil, 200, "js"},
+		{"/api/ping", html, 200, "pong"},
+		{"/api/nope", html, 404, ""},
+		{"/assets/app.3f2a.js", html, 404, ""},
+		{"/orders/42", map[string]string{"Accept": "application/json"}, 404, ""},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, tt.url, tt.header)
+		if w.Code != tt.status || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s %v: got %d %q", tt.url, tt.header, w.Code, w.Body.String())
+		}
+	}
+
+	r2 := New()
+	r2.Static("/", dir).SPA("index.html").FallbackDots(true)
+	if w := serve(r2, http.MethodGet, "/users/john.doe", html); w.Code != 200 || w.Body.String() != "<app>" {
+		t.Errorf("FallbackDots: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestStaticPrecompressedAndCache(t *testing.T) {
+
+	dir := writeFiles(t, map[string]string{
+		"app.js":     "console.log(1)",
+		"app.js.gz":  "gzip-bytes",
+		"app.js.br":  "br-bytes",
+		"index.html": "<app>",
+	})
+
+	r := New()
+	r.Static("/assets", dir).Precompressed().Cache(time.Hour, ".js").Cache(0, ".html")
+
+	tests := []struct {
+		accept, encoding, body string
+	}{
+		{"gzip, br", "br", "br-bytes"},
+		{"gzip, br;q=0", "gzip", "gzip-bytes"},
+		{"", "", "console.log(1)"},
+		{"identity", "", "console.log(1)"},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, "/assets/app.js", map[string]string{"Accept-Encoding": tt.accept})
+		h := w.Header()
+		if w.Code != 200 || h.Get("Content-Encoding") != tt.encoding || w.Body.String() != tt.body {
+			t.Errorf("Accept-Encoding %q: got %d %q %q", tt.accept, w.Code, h.Get("Content-Encoding"), w.Body.String())
+		}
+		if !strings.HasPrefix(h.Get("Content-Type"), "text/javascript") || h.Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: Content-Type %q Vary %q", tt.accept, h.Get("Content-Type"), h.Get("Vary"))
+		}
+		if h.Get("Cache-Control") != "public, max-age=3600" || h.Get("Expires") == "" {
+			t.Errorf("Accept-Encoding %q: Cache-Control %q Expires %q", tt.accept, h.Get("Cache-Control"), h.Get("Expires"))
+		}
+	}
+
+	w := serve(r, http.MethodGet, "/assets/index.html", nil)
+	etag := w.Header().Get("ETag")
+	if w.Header().Get("Cache-Control") != "no-cache" || !strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("index.html: Cache-Control %q ETag %q", w.Header().Get("Cache-Control"), etag)
+	}
+	if w := serve(r, http.MethodGet, "/assets/index.html", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match: got %d %q", w.Code, w.Body.String())
+	}
+	if w := serve(r, http.MethodGet, "/assets/index.html", map[string]string{"If-None-Match": `"other"`}); w.Code != 200 {
+		t.Errorf("If-None-Match mismatch: got %d", w.Code)
+	}
+
+	gz := serve(r, http.MethodGet, "/assets/app.js", map[string]string{"Accept-Encoding": "gzip"}).Header().Get("ETag")
+	plain := serve(r, http.MethodGet, "/assets/app.js", nil).Header().Get("ETag")
+	if gz == "" || gz == plain {
+		t.Errorf("compressed and plain variants share ETag %q", gz)
+	}
+}
+
+func TestStaticFS(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"css/a.css":   {Data: []byte("a{}")},
+		"favicon.ico": {Data: []byte("ico")},
+		"index.html":  {Data: []byte("<app>")},
+		".env":        {Data: []byte("SECRET=1")},
+	}
+
+	r := New()
+	r.StaticFS("/static", fsys)
+	r.FaviconFS(fsys)
+	r.FileFS("/", fsys, "index.html")
+
+	tests := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"/static/css/a.css", 200, "a{}"},
+		{"/static/", 200, "<app>"},
+		{"/static/.env", 404, ""},
+		{"/static/missing.css", 404, ""},
+		{"/favicon.ico", 200, "ico"},
+		{"/", 200, "<app>"},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, tt.url, nil)
+		if w.Code != tt.status || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("%s: got %d %q", tt.url, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestStaticConfinement(t *testing.T) {
+
+	secret := writeFiles(t, map[string]string{"pw": "secret"})
+	dir := writeFiles(t, map[string]string{
+		"sub/a.txt":       "a",
+		"docs/index.html": "docs",
+		".env":            "SECRET=1",
+		".git/config":     "git",
+	})
+	if err := os.Symlink(secret, filepath.Join(dir, "leak")); err != nil {
+		t.Skip("symlink not supported:", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "sub"), filepath.Join(dir, "inner")); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.Static("/s", dir)
+	r.Static("/l", dir).Listing(true)
+	r.Static("/dot", dir).Dotfiles(true)
+	r.StaticDir("/d", dir)
+
+	tests := []struct {
+		url      string
+		status   int
+		body     string
+		location string
+	}{
+		{"/s/sub/a.txt", 200, "a", ""},
+		{"/s/inner/a.txt", 200, "a", ""}, //根目录内的符号链接
+		{"/s/leak/pw", 404, "", ""},      //指向根目录之外的符号链接
+		{"/s/.env", 404, "", ""},
+		{"/s/.git/config", 404, "", ""},
+		{"/dot/.env", 200, "SECRET=1", ""},
+		{"/s/sub/", 404, "", ""}, //默认不列出目录
+		{"/s/docs", 301, "", "/s/docs/"},
+		{"/s/docs/", 200, "docs", ""},
+		{"/l/sub/", 200, "a.txt", ""},
+		{"/d/sub/a.txt", 200, "a", ""}, //StaticDir 去掉前缀
+		{"/d/sub/", 404, "", ""},
+		{"/d/leak/pw", 404, "", ""},
+		{"/d/docs/", 404, "", ""}, //StaticDir 不处理目录
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, tt.url, nil)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s: got %d %q %q", tt.url, w.Code, w.Body.String(), w.Header().Get("Location"))
+		}
+	}
+
+	if w := serve(r, http.MethodGet, "/l/", nil); strings.Contains(w.Body.String(), ".env") || strings.Contains(w.Body.String(), ".git") {
+		t.Errorf("listing shows dotfiles: %q", w.Body.String())
+	}
+
+	//绕过路由的路径清理，直接访问 rootDir
+	if _, err := rootDir(dir).Open("/../" + filepath.Base(secret) + "/pw"); err == nil {
+		t.Error("rootDir opened a file outside the root")
+	}
+}
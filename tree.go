@@ -0,0 +1,341 @@
+package gts
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// 路由表，分组子路由共用，Host 子路由各自独立
+type table struct {
+	trees    map[string]*node //按请求方法区分的路由树
+	files    *node            //静态资源挂载，按最长前缀匹配
+	handlers *node            //Handler 注册的前缀路由
+}
+
+func newTable() *table {
+
+	return &table{trees: make(map[string]*node), files: &node{}, handlers: &node{}}
+}
+
+// allowed 返回路径已注册的请求方法，逗号分隔
+func (t *table) allowed(url string) string {
+
+	var buf [4]Param
+	var methods []string
+	for method, root := range t.trees {
+
+		ps := Params(buf[:0])
+		if root.find(url, &ps) != nil {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return ""
+	}
+
+	has := func(m string) bool {
+		for _, v := range methods {
+			if v == m {
+				return true
+			}
+		}
+		return false
+	}
+	if has(http.MethodGet) && !has(http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+	if !has(http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// Param 路由路径参数，如 /user/:id 中的 id
+type Param struct {
+	Key   string
+	Value string
+}
+
+type Params []Param
+
+// Get 按名称取路径参数，不存在返回 ""
+func (ps Params) Get(name string) string {
+
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// node 压缩前缀树(radix tree)节点
+// 匹配优先级：静态前缀 > 带约束的参数({name:expr}) > 命名参数(:name) > 通配参数(*name)
+type node struct {
+	path     string  //静态前缀
+	indices  string  //子节点首字节，与 children 一一对应
+	children []*node //静态子节点
+	params   []*node //参数子节点，带约束的在前
+	wild     *node   //*name 子节点
+	name     string  //参数名
+	expr     string  //参数约束
+	check    func(string) bool
+	h        HandlerFunc
+	file     *static //静态资源挂载，只用于 files 树
+}
+
+func (n *node) child(c byte) *node {
+
+	for i := 0; i < len(n.indices); i++ {
+		if n.indices[i] == c {
+			return n.children[i]
+		}
+	}
+	return nil
+}
+
+// add 注册路由，path 中以 ":"、"*" 或 "{" 开头的分段为参数
+// {name:expr} 中 expr 为 constraints 里的约束名，否则按正则表达式匹配整个分段
+func (n *node) add(path string, h HandlerFunc, constraints map[string]func(string) bool) {
+
+	full := path
+	for {
+		w := nextWild(path)
+		if w.start < 0 {
+			n = n.addStatic(path)
+			break
+		}
+		n = n.addStatic(path[:w.start])
+
+		if w.name == "" {
+			panic("gts: empty param name in path " + full)
+		}
+		if w.end < len(path) && path[w.end] != '/' {
+			panic("gts: param " + path[w.start:w.end] + " must be a whole segment in path " + full)
+		}
+
+		if w.kind == '*' {
+			if w.end != len(path) {
+				panic("gts: wildcard *" + w.name + " must be the last segment in path " + full)
+			}
+			if n.wild != nil && n.wild.name != w.name {
+				panic("gts: wildcard *" + w.name + " conflicts with *" + n.wild.name + " in path " + full)
+			}
+			n.wild = &node{name: w.name, h: h}
+			return
+		}
+
+		n = n.addParam(w.name, w.expr, full, constraints)
+		path = path[w.end:]
+	}
+	n.h = h
+}
+
+func (n *node) addParam(name, expr, full string, constraints map[string]func(string) bool) *node {
+
+	for _, c := range n.params {
+		if c.expr == expr {
+			if c.name != name {
+				panic("gts: param " + name + " conflicts with " + c.name + " in path " + full)
+			}
+			return c
+		}
+	}
+
+	c := &node{name: name, expr: expr}
+	if expr == "" {
+		n.params = append(n.params, c)
+		return c
+	}
+
+	c.check = constraint(expr, constraints)
+	i := 0
+	for i < len(n.params) && n.params[i].expr != "" {
+		i++
+	}
+	n.params = append(n.params, nil)
+	copy(n.params[i+1:], n.params[i:])
+	n.params[i] = c
+	return c
+}
+
+// addStatic 插入静态前缀，必要时拆分已有节点，返回前缀末尾对应的节点
+func (n *node) addStatic(s string) *node {
+
+	for s != "" {
+		c := n.child(s[0])
+		if c == nil {
+			c = &node{path: s}
+			n.indices += s[:1]
+			n.children = append(n.children, c)
+			return c
+		}
+
+		l := commonPrefix(s, c.path)
+		if l < len(c.path) {
+			tail := *c
+			tail.path = c.path[l:]
+			*c = node{path: c.path[:l], indices: tail.path[:1], children: []*node{&tail}}
+		}
+		n, s = c, s[l:]
+	}
+	return n
+}
+
+// find 查找匹配的处理函数，path 为当前节点之后尚未匹配的部分
+// 静态分支匹配失败时回溯尝试参数分支，查找过程不分配内存
+func (n *node) find(path string, ps *Params) HandlerFunc {
+
+	if path == "" {
+		if n.h != nil {
+			return n.h
+		}
+	} else {
+		if c := n.child(path[0]); c != nil && strings.HasPrefix(path, c.path) {
+			if h := c.find(path[len(c.path):], ps); h != nil {
+				return h
+			}
+		}
+
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		for _, c := range n.params {
+			seg := path[:end]
+			if seg == "" || (c.check != nil && !c.check(seg)) {
+				continue
+			}
+			*ps = append(*ps, Param{Key: c.name, Value: seg})
+			if h := c.find(path[end:], ps); h != nil {
+				return h
+			}
+			*ps = (*ps)[:len(*ps)-1]
+		}
+	}
+
+	if n.wild != nil {
+		*ps = append(*ps, Param{Key: n.wild.name, Value: path})
+		return n.wild.h
+	}
+	return nil
+}
+
+// findCase 不区分大小写查找，返回路由中注册的写法，参数部分保留请求中的原值
+func (n *node) findCase(path string, b []byte) ([]byte, bool) {
+
+	if path == "" {
+		if n.h != nil {
+			return b, true
+		}
+	} else {
+		for _, c := range n.children {
+			if len(path) >= len(c.path) && strings.EqualFold(path[:len(c.path)], c.path) {
+				if fixed, ok := c.findCase(path[len(c.path):], append(b, c.path...)); ok {
+					return fixed, true
+				}
+			}
+		}
+
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		for _, c := range n.params {
+			seg := path[:end]
+			if seg == "" || (c.check != nil && !c.check(seg)) {
+				continue
+			}
+			if fixed, ok := c.findCase(path[end:], append(b, seg...)); ok {
+				return fixed, true
+			}
+		}
+	}
+
+	if n.wild != nil {
+		return append(b, path...), true
+	}
+	return nil, false
+}
+
+// longest 最长前缀匹配，只用于静态路径，前缀必须在 "/" 处断开
+// 返回匹配的节点及去掉前缀后剩余的路径
+func (n *node) longest(path string) (*node, string) {
+
+	var found *node
+	rest := path
+	last := byte(0)
+	for {
+		if (n.h != nil || n.file != nil) && (path == "" || path[0] == '/' || last == '/') {
+			found, rest = n, path
+		}
+		if path == "" {
+			return found, rest
+		}
+		c := n.child(path[0])
+		if c == nil || !strings.HasPrefix(path, c.path) {
+			return found, rest
+		}
+		n, path, last = c, path[len(c.path):], c.path[len(c.path)-1]
+	}
+}
+
+// 路径中的参数
+type wildcard struct {
+	start, end int
+	kind       byte // ':' '*' '{'
+	name, expr string
+}
+
+// nextWild 返回 path 中第一个位于分段开头的参数，没有时 start 为 -1
+func nextWild(path string) wildcard {
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if (c != ':' && c != '*' && c != '{') || (i > 0 && path[i-1] != '/') {
+			continue
+		}
+
+		w := wildcard{start: i, kind: c}
+		if c == '{' {
+			depth := 0
+			for j := i; j < len(path); j++ {
+				if path[j] == '{' {
+					depth++
+				} else if path[j] == '}' {
+					depth--
+					if depth == 0 {
+						w.end = j + 1
+						w.name = path[i+1 : j]
+						if k := strings.IndexByte(w.name, ':'); k >= 0 {
+							w.name, w.expr = w.name[:k], w.name[k+1:]
+						}
+						return w
+					}
+				}
+			}
+			panic("gts: unclosed { in path " + path)
+		}
+
+		w.end = strings.IndexByte(path[i:], '/')
+		if w.end < 0 {
+			w.end = len(path)
+		} else {
+			w.end += i
+		}
+		w.name = path[i+1 : w.end]
+		return w
+	}
+	return wildcard{start: -1}
+}
+
+func commonPrefix(a, b string) int {
+
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
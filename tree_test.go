@@ -0,0 +1,222 @@
+package gts
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// routeTree 注册 paths，命中的处理函数把注册路径写入 *hit
+func routeTree(paths ...string) (root *node, hit *string) {
+
+	root, hit = &node{}, new(string)
+	for _, p := range paths {
+		p := p
+		root.add(p, func(r *http.Request, c *Context) { *hit = p }, defaultConstraints())
+	}
+	return root, hit
+}
+
+func TestTreeFind(t *testing.T) {
+
+	root, hit := routeTree(
+		"/",
+		"/user/new",
+		"/user/:id",
+		"/user/:id/posts",
+		"/files/readme",
+		"/files/*path",
+		"/a/:x",
+		"/a/*rest",
+		"/src/{n:int}",
+		"/src/:name",
+	)
+
+	tests := []struct {
+		url, route, params string
+	}{
+		{"/", "/", ""},
+		{"/user/new", "/user/new", ""},                   //静态优先于参数
+		{"/user/newx", "/user/:id", "id=newx"},           //静态前缀部分匹配后回溯
+		{"/user/new/posts", "/user/:id/posts", "id=new"}, //静态节点没有后续分支时回溯到参数
+		{"/user/42/posts", "/user/:id/posts", "id=42"},
+		{"/files/readme", "/files/readme", ""},
+		{"/files/a/b.txt", "/files/*path", "path=a/b.txt"},
+		{"/a/b", "/a/:x", "x=b"}, //参数优先于通配
+		{"/a/b/c", "/a/*rest", "rest=b/c"},
+		{"/src/12", "/src/{n:int}", "n=12"}, //带约束的参数优先
+		{"/src/abc", "/src/:name", "name=abc"},
+		{"/user", "", ""},
+		{"/user/", "", ""},
+	}
+	for _, tt := range tests {
+
+		*hit = ""
+		ps := Params{}
+		if h := root.find(tt.url, &ps); h != nil {
+			h(nil, nil)
+		}
+		if *hit != tt.route {
+			t.Errorf("%s: matched %q, want %q", tt.url, *hit, tt.route)
+			continue
+		}
+
+		var kv []string
+		for _, p := range ps {
+			kv = append(kv, p.Key+"="+p.Value)
+		}
+		if params := strings.Join(kv, ","); params != tt.params {
+			t.Errorf("%s: params %q, want %q", tt.url, params, tt.params)
+		}
+	}
+}
+
+// 重叠前缀按最长前缀匹配，且结果稳定
+func TestTreeLongest(t *testing.T) {
+
+	root, hit := routeTree()
+	for _, p := range []string{"/api/v2", "/api", "/api/v2/admin"} {
+		p := p
+		root.addStatic(p).h = func(r *http.Request, c *Context) { *hit = p }
+	}
+
+	tests := []struct {
+		url, prefix, rest string
+	}{
+		{"/api", "/api", ""},
+		{"/api/v1/users", "/api", "/v1/users"},
+		{"/api/v2", "/api/v2", ""},
+		{"/api/v2/users", "/api/v2", "/users"},
+		{"/api/v2x", "/api", "/v2x"}, //前缀必须在 "/" 处断开
+		{"/api/v2/admin/x", "/api/v2/admin", "/x"},
+		{"/apix", "", ""},
+	}
+	for i := 0; i < 100; i++ {
+		for _, tt := range tests {
+
+			*hit = ""
+			n, rest := root.longest(tt.url)
+			if n != nil {
+				n.h(nil, nil)
+			}
+			if *hit != tt.prefix || (n != nil && rest != tt.rest) {
+				t.Fatalf("%s: matched %q rest %q, want %q rest %q", tt.url, *hit, rest, tt.prefix, tt.rest)
+			}
+		}
+	}
+}
+
+func TestTreeConflicts(t *testing.T) {
+
+	for _, paths := range [][]string{
+		{"/user/:id", "/user/:name"},
+		{"/files/*a", "/files/*b"},
+		{"/files/*a/x"},
+		{"/user/:"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: want panic", paths)
+				}
+			}()
+			routeTree(paths...)
+		}()
+	}
+}
+
+func TestTreeFindAllocs(t *testing.T) {
+
+	root, _ := routeTree("/user/:id/posts/:post", "/static/*path", "/src/{n:int}")
+	for _, url := range []string{"/user/1/posts/2", "/static/css/a.css", "/src/12", "/nope"} {
+
+		var buf [4]Param
+		allocs := testing.AllocsPerRun(100, func() {
+			ps := Params(buf[:0])
+			root.find(url, &ps)
+		})
+		if allocs != 0 {
+			t.Errorf("%s: %v allocs per lookup, want 0", url, allocs)
+		}
+	}
+}
+
+// 模拟 radix tree 之前的实现：遍历 map 按 strings.HasPrefix 匹配
+func linearMatch(routes map[string]HandlerFunc, url string) HandlerFunc {
+
+	for k, f := range routes {
+		if strings.HasPrefix(url+"/", k+"/") {
+			return f
+		}
+	}
+	return nil
+}
+
+func benchPrefixes(n int) []string {
+
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = "/svc" + strconv.Itoa(i) + "/api"
+	}
+	return paths
+}
+
+func BenchmarkLinearPrefix(b *testing.B) {
+
+	routes := make(map[string]HandlerFunc)
+	for _, p := range benchPrefixes(50) {
+		routes[p] = func(r *http.Request, c *Context) {}
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		linearMatch(routes, "/svc49/api/users")
+	}
+}
+
+func BenchmarkTreeLongest(b *testing.B) {
+
+	root := &node{}
+	for _, p := range benchPrefixes(50) {
+		root.addStatic(p).h = func(r *http.Request, c *Context) {}
+	}
+	if allocs := testing.AllocsPerRun(100, func() { root.longest("/svc49/api/users") }); allocs != 0 {
+		b.Fatalf("%v allocs per lookup, want 0", allocs)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root.longest("/svc49/api/users")
+	}
+}
+
+func BenchmarkTreeFind(b *testing.B) {
+
+	var paths []string
+	for _, p := range benchPrefixes(50) {
+		paths = append(paths, p+"/users/:id", p+"/files/*path")
+	}
+	root, _ := routeTree(paths...)
+
+	for _, url := range []string{"/svc49/api/users/42", "/svc49/api/files/a/b.txt"} {
+		b.Run(url, func(b *testing.B) {
+
+			var buf [4]Param
+			find := func() {
+				ps := Params(buf[:0])
+				root.find(url, &ps)
+			}
+			if allocs := testing.AllocsPerRun(100, find); allocs != 0 {
+				b.Fatalf("%v allocs per lookup, want 0", allocs)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				find()
+			}
+		})
+	}
+}
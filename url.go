@@ -0,0 +1,116 @@
+package gts
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Route 注册路由的返回值，用于给路由命名
+//
+//	route.Get("/user/:id", userHandler).Name("user")
+//	route.URL("user", "id", 42, "tab", "posts") // => /user/42?tab=posts
+type Route struct {
+	router *Router
+	infos  []*RouteInfo
+}
+
+// Name 命名路由，名称重复时 panic
+func (r *Route) Name(name string) *Route {
+
+	if name == "" {
+		panic("gts: empty route name")
+	}
+	path := r.infos[0].Path
+	if old, ok := r.router.names[name]; ok && old != path {
+		panic("gts: route name " + name + " already used by " + old)
+	}
+	r.router.names[name] = path
+	for _, info := range r.infos {
+		info.Name = name
+	}
+	return r
+}
+
+// URL 按路由名称生成路径，params 为成对的参数名和值
+// 与路径参数同名的填入路径，其余作为查询参数；路由或路径参数不存在时返回错误
+func (p *Router) URL(name string, params ...interface{}) (string, error) {
+
+	pattern, ok := p.names[name]
+	if !ok {
+		return "", fmt.Errorf("gts: route %q not found", name)
+	}
+	return buildURL(name, pattern, params)
+}
+
+// URLFor 同 Router.URL，出错时 panic
+func (c *Context) URLFor(name string, params ...interface{}) string {
+
+	u, err := c.router.URL(name, params...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return u
+}
+
+func buildURL(name, pattern string, params []interface{}) (string, error) {
+
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("gts: route %q: params must be key/value pairs", name)
+	}
+
+	keys := make([]string, 0, len(params)/2)
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		k, ok := params[i].(string)
+		if !ok {
+			return "", fmt.Errorf("gts: route %q: param name %v is not a string", name, params[i])
+		}
+		keys = append(keys, k)
+		values[k] = fmt.Sprint(params[i+1])
+	}
+
+	var b strings.Builder
+	used := make(map[string]bool)
+	path := pattern
+	for {
+		w := nextWild(path)
+		if w.start < 0 {
+			b.WriteString(path)
+			break
+		}
+		b.WriteString(path[:w.start])
+
+		v, ok := values[w.name]
+		if !ok {
+			return "", fmt.Errorf("gts: route %q: missing param %q", name, w.name)
+		}
+		used[w.name] = true
+
+		if w.kind == '*' {
+			segs := strings.Split(v, "/")
+			for j := range segs {
+				segs[j] = url.PathEscape(segs[j])
+			}
+			b.WriteString(strings.Join(segs, "/"))
+		} else {
+			if v == "" {
+				return "", fmt.Errorf("gts: route %q: empty param %q", name, w.name)
+			}
+			b.WriteString(url.PathEscape(v))
+		}
+		path = path[w.end:]
+	}
+
+	query := url.Values{}
+	for _, k := range keys {
+		if !used[k] {
+			query.Add(k, values[k])
+		}
+	}
+	if len(query) > 0 {
+		b.WriteString("?")
+		b.WriteString(query.Encode())
+	}
+	return b.String(), nil
+}
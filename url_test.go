@@ -0,0 +1,78 @@
+package gts
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+
+	h := func(req *http.Request, c *Context) {}
+	r := New()
+	r.Get("/", h).Name("home")
+	r.Get("/user/:id", h).Name("user")
+	r.Post("/user/:id", h).Name("user") //同名同路径允许重复命名
+	r.Get("/files/*path", h).Name("file")
+	r.Get("/src/{n:int}", h).Name("src")
+	r.Group("/api", func(g *Router) {
+		g.Get("/items/:id", h).Name("item")
+	})
+
+	tests := []struct {
+		name   string
+		params []interface{}
+		url    string
+	}{
+		{"home", nil, "/"},
+		{"home", []interface{}{"q", "a&b=c", "lang", "zh"}, "/?lang=zh&q=a%26b%3Dc"},
+		{"user", []interface{}{"id", 42, "tab", "posts"}, "/user/42?tab=posts"},
+		{"user", []interface{}{"id", "a b/c?"}, "/user/a%20b%2Fc%3F"},
+		{"file", []interface{}{"path", "css/a b.css"}, "/files/css/a%20b.css"}, //通配参数保留 "/"
+		{"file", []interface{}{"path", "x?y/%"}, "/files/x%3Fy/%25"},
+		{"file", []interface{}{"path", ""}, "/files/"},
+		{"src", []interface{}{"n", 12}, "/src/12"},
+		{"item", []interface{}{"id", 1}, "/api/items/1"},
+	}
+	for _, tt := range tests {
+		u, err := r.URL(tt.name, tt.params...)
+		if err != nil || u != tt.url {
+			t.Errorf("%s %v: got %q %v, want %q", tt.name, tt.params, u, err, tt.url)
+		}
+	}
+
+	for _, tt := range []struct {
+		name   string
+		params []interface{}
+	}{
+		{"nope", nil},
+		{"user", nil},
+		{"user", []interface{}{"tab", "posts"}},
+		{"user", []interface{}{"id", ""}},
+		{"user", []interface{}{"id"}},
+		{"user", []interface{}{1, 2}},
+	} {
+		if u, err := r.URL(tt.name, tt.params...); err == nil {
+			t.Errorf("%s %v: got %q, want error", tt.name, tt.params, u)
+		}
+	}
+
+	c := &Context{router: r}
+	if u := c.URLFor("user", "id", 7); u != "/user/7" {
+		t.Errorf("URLFor: got %q", u)
+	}
+	for _, fn := range []func(){
+		func() { c.URLFor("nope") },
+		func() { c.URLFor("user") },
+		func() { r.Get("/profile/:id", h).Name("user") }, //同名不同路径
+		func() { r.Get("/x", h).Name("") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("want panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
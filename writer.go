@@ -0,0 +1,129 @@
+package gts
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter gts 包装后的 Context.Writer，记录状态码、响应字节数及是否已提交
+// 始终实现 http.Flusher、http.Hijacker、http.Pusher，类型断言不能说明底层是否支持：
+// 底层不支持时 Flush 不执行任何操作，Hijack、Push 返回错误；
+// SSE 等流式输出应调用 FlushError 判断，或通过 Unwrap 取得原始的 ResponseWriter
+//
+//	if rw, ok := c.Writer.(gts.ResponseWriter); ok && rw.Written() {
+//		return
+//	}
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+
+	// Status 已写入的状态码，尚未写入时为 0
+	Status() int
+	// Size 已写入的响应体字节数
+	Size() int
+	// Written 状态码或响应体是否已写入，写入后再调用 WriteHeader 会被忽略
+	Written() bool
+	// FlushError 同 Flush，底层不支持时返回 http.ErrNotSupported，http.ResponseController 同样使用该方法
+	FlushError() error
+	// Unwrap 返回原始的 ResponseWriter
+	Unwrap() http.ResponseWriter
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+
+	if w.status != 0 {
+		return //响应头已提交，避免 "superfluous WriteHeader"
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code) //1xx 信息响应可多次发送，不算提交
+		return
+	}
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+
+	return w.size
+}
+
+func (w *responseWriter) Written() bool {
+
+	return w.status != 0
+}
+
+// Flush 底层不支持时不执行任何操作
+func (w *responseWriter) Flush() {
+
+	w.FlushError()
+}
+
+func (w *responseWriter) FlushError() error {
+
+	switch f := w.ResponseWriter.(type) {
+	case interface{ FlushError() error }:
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		return f.FlushError()
+	case http.Flusher:
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+		return nil
+	}
+	return http.ErrNotSupported
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("gts: response does not implement http.Hijacker")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols //连接已接管，不能再写入响应
+	}
+	return conn, rw, err
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap 返回原始的 ResponseWriter，http.ResponseController 也通过它访问底层的 SetReadDeadline 等方法
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+
+	return w.ResponseWriter
+}